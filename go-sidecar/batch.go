@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+)
+
+// batchRequest is the POST /_batch body. Callers provide either an
+// explicit list of paths or a sitemap URL to expand into paths.
+type batchRequest struct {
+	Paths       []string `json:"paths,omitempty"`
+	Sitemap     string   `json:"sitemap,omitempty"`
+	Format      string   `json:"format,omitempty"` // "ndjson" (default) or "bundle"
+	TokenBudget int      `json:"token_budget,omitempty"`
+}
+
+// batchDocument is one converted page in a batch/corpus response.
+type batchDocument struct {
+	URL      string   `json:"url"`
+	Metadata Metadata `json:"metadata,omitempty"`
+	Markdown string   `json:"markdown,omitempty"`
+	Tokens   int      `json:"tokens,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// urlSet mirrors the <urlset><url><loc>...</loc></url></urlset> shape of
+// a sitemap.xml; everything else in the document is ignored.
+type urlSet struct {
+	XMLName xml.Name `xml:"urlset"`
+	URLs    []struct {
+		Loc string `xml:"loc"`
+	} `xml:"url"`
+}
+
+// batchHandler handles POST /_batch: convert an explicit list of paths or
+// a sitemap's worth of paths, streaming results back as they complete.
+func batchHandler(cfg Config, client *http.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req batchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		runBatch(w, r, cfg, client, req)
+	}
+}
+
+// corpusHandler handles GET /_corpus?sitemap=...&format=...: the same
+// sitemap-driven batch, addressed as a simple GET for clients that can't
+// issue a POST with a JSON body.
+func corpusHandler(cfg Config, client *http.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := batchRequest{
+			Sitemap: r.URL.Query().Get("sitemap"),
+			Format:  r.URL.Query().Get("format"),
+		}
+		if req.Sitemap == "" {
+			http.Error(w, "sitemap query parameter is required", http.StatusBadRequest)
+			return
+		}
+		runBatch(w, r, cfg, client, req)
+	}
+}
+
+// runBatch resolves the set of paths to convert, fans them out across a
+// bounded worker pool, and streams the results in the requested format.
+func runBatch(w http.ResponseWriter, r *http.Request, cfg Config, client *http.Client, req batchRequest) {
+	paths := req.Paths
+	if req.Sitemap != "" {
+		if !sameOrigin(req.Sitemap, cfg.OriginBaseURL) {
+			http.Error(w, "sitemap must be same-origin as the configured origin", http.StatusBadRequest)
+			return
+		}
+		fromSitemap, err := fetchSitemapPaths(r.Context(), client, req.Sitemap)
+		if err != nil {
+			slog.Error("Failed to fetch sitemap", "sitemap", req.Sitemap, "error", err)
+			http.Error(w, "failed to fetch sitemap", http.StatusBadGateway)
+			return
+		}
+		paths = append(paths, fromSitemap...)
+	}
+	if len(paths) == 0 {
+		http.Error(w, "no paths to convert: provide 'paths' or 'sitemap'", http.StatusBadRequest)
+		return
+	}
+
+	concurrency := cfg.MaxConcurrency
+	if concurrency <= 0 {
+		concurrency = 5
+	}
+
+	docs := convertBatch(r.Context(), cfg, client, paths, concurrency, req.TokenBudget)
+
+	if req.Format == "bundle" {
+		writeBundle(w, docs)
+		return
+	}
+	writeNDJSON(w, docs)
+}
+
+// convertBatch fetches and converts each path concurrently, bounded by
+// concurrency in-flight requests, stopping early once tokenBudget tokens
+// have been produced (0 means unbounded). Results preserve the order of
+// paths.
+func convertBatch(ctx context.Context, cfg Config, client *http.Client, paths []string, concurrency, tokenBudget int) []batchDocument {
+	docs := make([]batchDocument, len(paths))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var tokensUsed int64
+	var budgetExceeded int32
+
+	for i, path := range paths {
+		if atomic.LoadInt32(&budgetExceeded) != 0 {
+			break
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, path string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if atomic.LoadInt32(&budgetExceeded) != 0 {
+				return
+			}
+
+			pathURL, err := url.Parse(path)
+			if err != nil {
+				docs[i] = batchDocument{URL: path, Error: fmt.Sprintf("invalid path: %v", err)}
+				return
+			}
+			originURL := buildOriginURL(cfg.OriginBaseURL, pathURL)
+			mdText, meta, tokenCount, err := convertOriginURL(ctx, cfg, client, originURL)
+			if err != nil {
+				docs[i] = batchDocument{URL: originURL, Error: err.Error()}
+				return
+			}
+			docs[i] = batchDocument{URL: originURL, Metadata: meta, Markdown: mdText, Tokens: tokenCount}
+
+			if tokenBudget > 0 && atomic.AddInt64(&tokensUsed, int64(tokenCount)) >= int64(tokenBudget) {
+				atomic.StoreInt32(&budgetExceeded, 1)
+			}
+		}(i, path)
+	}
+
+	wg.Wait()
+
+	// Drop the zero-value slots left by paths skipped once the budget was hit.
+	trimmed := docs[:0]
+	for _, d := range docs {
+		if d.URL != "" {
+			trimmed = append(trimmed, d)
+		}
+	}
+	return trimmed
+}
+
+// convertOriginURL fetches and converts a single already-built origin URL.
+// It is the shared core used by both the single-page handler and batch
+// conversion.
+func convertOriginURL(ctx context.Context, cfg Config, client *http.Client, originURL string) (string, Metadata, int, error) {
+	return convertOriginURLAs(ctx, cfg, client, originURL, "markdown-sidecar/0.1")
+}
+
+// convertOriginURLAs is convertOriginURL with an overridable User-Agent, for
+// callers (such as the batch CLI) that let the operator configure it.
+func convertOriginURLAs(ctx context.Context, cfg Config, client *http.Client, originURL, userAgent string) (string, Metadata, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, originURL, nil)
+	if err != nil {
+		return "", Metadata{}, 0, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Accept", "text/html")
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", Metadata{}, 0, fmt.Errorf("fetch origin: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return "", Metadata{}, 0, fmt.Errorf("origin returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", Metadata{}, 0, fmt.Errorf("read origin response: %w", err)
+	}
+
+	mdText, meta := htmlToMarkdown(string(body), cfg.ExtraSelectors, extractorForName(cfg.Extractor), rendererForName(cfg.Renderer, ParseRenderOptions(cfg.RenderExtensions)), cfg.FrontMatter, originURL, imageOptionsFromConfig(cfg, client))
+	tokenCount := countTokens(mdText, cfg.TokenModel)
+	return mdText, meta, tokenCount, nil
+}
+
+// sameOrigin reports whether candidate has the same scheme and host as
+// originBaseURL. A request handler that lets a caller supply an arbitrary
+// sitemap URL would otherwise let that caller make this server fetch any
+// URL it likes, including internal services the per-page fetch path
+// could never reach (that path only ever builds URLs from cfg.OriginBaseURL
+// via buildOriginURL). Requiring the sitemap to be same-origin closes that
+// off without needing a loopback/private-IP denylist of its own.
+func sameOrigin(candidate, originBaseURL string) bool {
+	c, err := url.Parse(candidate)
+	if err != nil || c.Host == "" {
+		return false
+	}
+	base, err := url.Parse(originBaseURL)
+	if err != nil {
+		return false
+	}
+	return c.Scheme == base.Scheme && c.Host == base.Host
+}
+
+// fetchSitemapPaths downloads and parses a sitemap.xml, returning its
+// <loc> entries. Callers that take sitemapURL from an HTTP request must
+// confirm it is same-origin as cfg.OriginBaseURL themselves (see
+// runBatch's sameOrigin check) before calling this; the batch CLI's
+// --sitemap flag is operator-supplied and does not need that check.
+func fetchSitemapPaths(ctx context.Context, client *http.Client, sitemapURL string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sitemapURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build sitemap request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch sitemap: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("sitemap returned status %d", resp.StatusCode)
+	}
+
+	var set urlSet
+	if err := xml.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("parse sitemap: %w", err)
+	}
+
+	paths := make([]string, 0, len(set.URLs))
+	for _, u := range set.URLs {
+		if u.Loc != "" {
+			paths = append(paths, u.Loc)
+		}
+	}
+	return paths, nil
+}
+
+// writeNDJSON streams one JSON document per line.
+func writeNDJSON(w http.ResponseWriter, docs []batchDocument) {
+	w.Header().Set("Content-Type", "application/x-ndjson; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	for _, doc := range docs {
+		enc.Encode(doc)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// writeBundle concatenates every document into a single Markdown file,
+// separated by a front-matter-style `url:` comment per document.
+func writeBundle(w http.ResponseWriter, docs []batchDocument) {
+	w.Header().Set("Content-Type", markdownMIMEType+"; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	for i, doc := range docs {
+		if i > 0 {
+			fmt.Fprint(w, "\n\n")
+		}
+		fmt.Fprintf(w, "<!-- url: %s -->\n\n", doc.URL)
+		if doc.Error != "" {
+			fmt.Fprintf(w, "<!-- error: %s -->\n", doc.Error)
+			continue
+		}
+		fmt.Fprint(w, doc.Markdown)
+	}
+}
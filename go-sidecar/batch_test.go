@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBatchHandler_NDJSONFromExplicitPaths(t *testing.T) {
+	sampleHTML := loadFixture(t)
+
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, sampleHTML)
+	}))
+	defer origin.Close()
+
+	cfg := Config{
+		OriginBaseURL:  origin.URL,
+		OriginTimeout:  10_000_000_000,
+		TokenModel:     "cl100k_base",
+		MaxConcurrency: 2,
+	}
+	client := origin.Client()
+	handler := batchHandler(cfg, client)
+
+	body := strings.NewReader(`{"paths": ["/page-a", "/page-b"]}`)
+	req := httptest.NewRequest(http.MethodPost, "/_batch", body)
+	w := httptest.NewRecorder()
+
+	handler(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	var docs []batchDocument
+	for scanner.Scan() {
+		var doc batchDocument
+		if err := json.Unmarshal(scanner.Bytes(), &doc); err != nil {
+			t.Fatalf("failed to decode NDJSON line: %v", err)
+		}
+		docs = append(docs, doc)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("expected 2 documents, got %d", len(docs))
+	}
+	for _, doc := range docs {
+		if !strings.Contains(doc.Markdown, "# Willkommen") {
+			t.Errorf("expected converted markdown for %s, got %q", doc.URL, doc.Markdown)
+		}
+	}
+}
+
+func TestBatchHandler_BundleFormat(t *testing.T) {
+	sampleHTML := loadFixture(t)
+
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, sampleHTML)
+	}))
+	defer origin.Close()
+
+	cfg := Config{
+		OriginBaseURL:  origin.URL,
+		OriginTimeout:  10_000_000_000,
+		TokenModel:     "cl100k_base",
+		MaxConcurrency: 2,
+	}
+	client := origin.Client()
+	handler := batchHandler(cfg, client)
+
+	body := strings.NewReader(`{"paths": ["/page-a"], "format": "bundle"}`)
+	req := httptest.NewRequest(http.MethodPost, "/_batch", body)
+	w := httptest.NewRecorder()
+
+	handler(w, req)
+
+	resp := w.Result()
+	if ct := resp.Header.Get("Content-Type"); !strings.Contains(ct, "text/markdown") {
+		t.Errorf("expected markdown content-type, got %q", ct)
+	}
+	if !strings.Contains(w.Body.String(), "<!-- url:") {
+		t.Error("expected per-document url separator in bundle")
+	}
+}
+
+func TestBatchHandler_NoPathsOrSitemapIsBadRequest(t *testing.T) {
+	cfg := Config{OriginBaseURL: "http://localhost", TokenModel: "cl100k_base"}
+	handler := batchHandler(cfg, http.DefaultClient)
+
+	req := httptest.NewRequest(http.MethodPost, "/_batch", strings.NewReader(`{}`))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Result().StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Result().StatusCode)
+	}
+}
+
+func TestCorpusHandler_RequiresSitemapParam(t *testing.T) {
+	cfg := Config{OriginBaseURL: "http://localhost", TokenModel: "cl100k_base"}
+	handler := corpusHandler(cfg, http.DefaultClient)
+
+	req := httptest.NewRequest(http.MethodGet, "/_corpus", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Result().StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Result().StatusCode)
+	}
+}
+
+func TestCorpusHandler_ExpandsSitemap(t *testing.T) {
+	sampleHTML := loadFixture(t)
+	var mux http.ServeMux
+	mux.HandleFunc("/sitemap.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/page-a</loc></url>
+  <url><loc>/page-b</loc></url>
+</urlset>`)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, sampleHTML)
+	})
+	origin := httptest.NewServer(&mux)
+	defer origin.Close()
+
+	cfg := Config{
+		OriginBaseURL:  origin.URL,
+		OriginTimeout:  10_000_000_000,
+		TokenModel:     "cl100k_base",
+		MaxConcurrency: 2,
+	}
+	client := origin.Client()
+	handler := corpusHandler(cfg, client)
+
+	req := httptest.NewRequest(http.MethodGet, "/_corpus?sitemap="+origin.URL+"/sitemap.xml", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	count := 0
+	for scanner.Scan() {
+		count++
+	}
+	if count != 2 {
+		t.Errorf("expected 2 documents from sitemap, got %d", count)
+	}
+}
+
+func TestConvertBatch_StopsEarlyOnTokenBudget(t *testing.T) {
+	sampleHTML := loadFixture(t)
+
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, sampleHTML)
+	}))
+	defer origin.Close()
+
+	cfg := Config{
+		OriginBaseURL: origin.URL,
+		OriginTimeout: 10_000_000_000,
+		TokenModel:    "cl100k_base",
+	}
+	client := origin.Client()
+
+	docs := convertBatch(req(t).Context(), cfg, client, []string{"/a", "/b", "/c", "/d", "/e"}, 1, 1)
+	if len(docs) == 0 {
+		t.Fatal("expected at least one document before the budget was hit")
+	}
+	if len(docs) == len([]string{"/a", "/b", "/c", "/d", "/e"}) {
+		t.Error("expected the token budget to stop the batch before converting every path")
+	}
+}
+
+func TestCorpusHandler_RejectsCrossOriginSitemap(t *testing.T) {
+	cfg := Config{OriginBaseURL: "https://example.com", TokenModel: "cl100k_base"}
+	handler := corpusHandler(cfg, http.DefaultClient)
+
+	req := httptest.NewRequest(http.MethodGet, "/_corpus?sitemap=http://169.254.169.254/latest/meta-data/", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Result().StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for a cross-origin sitemap URL, got %d", w.Result().StatusCode)
+	}
+}
+
+func TestSameOrigin(t *testing.T) {
+	cases := []struct {
+		candidate, base string
+		want            bool
+	}{
+		{"https://example.com/sitemap.xml", "https://example.com", true},
+		{"https://example.com:8443/sitemap.xml", "https://example.com", false},
+		{"http://example.com/sitemap.xml", "https://example.com", false},
+		{"http://169.254.169.254/latest/meta-data/", "https://example.com", false},
+		{"not a url", "https://example.com", false},
+	}
+	for _, c := range cases {
+		if got := sameOrigin(c.candidate, c.base); got != c.want {
+			t.Errorf("sameOrigin(%q, %q) = %v, want %v", c.candidate, c.base, got, c.want)
+		}
+	}
+}
+
+func req(t *testing.T) *http.Request {
+	t.Helper()
+	return httptest.NewRequest(http.MethodGet, "/", nil)
+}
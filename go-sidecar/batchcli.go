@@ -0,0 +1,314 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// batchCLIResult is one URL's outcome in a "sidecar -batch" run, and the
+// shape of each entry in the final summary report.
+type batchCLIResult struct {
+	URL    string `json:"url"`
+	File   string `json:"file,omitempty"`
+	Chunks int    `json:"chunks,omitempty"`
+	Tokens int    `json:"tokens,omitempty"`
+	Bytes  int    `json:"bytes,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// batchChunkLine is one line of "--output=jsonl" output: a Chunk plus the
+// URL it came from, so a RAG pipeline ingesting the stream can cite it.
+type batchChunkLine struct {
+	URL string `json:"url"`
+	Chunk
+}
+
+// ndjsonWriter serializes concurrent writers of NDJSON lines to a single
+// io.Writer (the worker pool's goroutines all feed --output=jsonl at once).
+type ndjsonWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func newNDJSONWriter(w io.Writer) *ndjsonWriter {
+	return &ndjsonWriter{enc: json.NewEncoder(w)}
+}
+
+func (w *ndjsonWriter) Write(v any) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.enc.Encode(v)
+}
+
+// batchCLIOptions bundles the per-run settings convertOneForCLI needs for
+// every URL in the worker pool.
+type batchCLIOptions struct {
+	outputDir     string
+	userAgent     string
+	retries       int
+	respectRobots bool
+	robots        *robotsChecker
+	chunkTokens   int
+	chunkOverlap  int
+	output        string // "files" (default) or "jsonl"
+	jsonlOut      *ndjsonWriter
+}
+
+// batchCLISummary is the JSON report printed to stdout once a batch run
+// finishes.
+type batchCLISummary struct {
+	Total     int              `json:"total"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Results   []batchCLIResult `json:"results"`
+}
+
+// runBatchCLI implements "sidecar -batch": it reads a list of URLs from
+// stdin, a --urls-file, or a --sitemap, fetches and converts each with a
+// bounded worker pool, and writes one Markdown file per URL to
+// --output-dir (one file per chunk with --chunk-tokens, or JSON lines on
+// stdout instead of files with --output=jsonl). It returns the process
+// exit code.
+func runBatchCLI(args []string, cfg Config) int {
+	fs := flag.NewFlagSet("batch", flag.ExitOnError)
+	concurrency := fs.Int("concurrency", 5, "number of concurrent fetches")
+	urlsFile := fs.String("urls-file", "", "file containing one URL per line (default: read from stdin)")
+	sitemap := fs.String("sitemap", "", "sitemap.xml URL to expand into a URL list")
+	outputDir := fs.String("output-dir", ".", "directory to write converted Markdown files to")
+	timeout := fs.Duration("timeout", 10*time.Second, "per-request timeout")
+	userAgent := fs.String("user-agent", "markdown-sidecar/0.1", "User-Agent sent to origin servers")
+	retries := fs.Int("retries", 3, "retry attempts per URL after a failed fetch or conversion")
+	respectRobots := fs.Bool("respect-robots", true, "skip URLs disallowed by robots.txt")
+	chunkTokens := fs.Int("chunk-tokens", 0, "split each page into chunks of at most this many tokens; 0 disables chunking")
+	chunkOverlap := fs.Int("chunk-overlap", 0, "tokens of overlap carried from the end of one chunk into the start of the next")
+	output := fs.String("output", "files", `output mode: "files" (default, one Markdown file per URL/chunk) or "jsonl" (stream chunks as JSON lines to stdout)`)
+	fs.Parse(args)
+
+	client := &http.Client{Timeout: *timeout}
+
+	urls, err := collectBatchURLs(client, *urlsFile, *sitemap)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "batch: %v\n", err)
+		return 1
+	}
+	if len(urls) == 0 {
+		fmt.Fprintln(os.Stderr, "batch: no URLs to convert")
+		return 1
+	}
+
+	if *output != "jsonl" {
+		if err := os.MkdirAll(*outputDir, 0o755); err != nil {
+			fmt.Fprintf(os.Stderr, "batch: create output dir: %v\n", err)
+			return 1
+		}
+	}
+
+	opts := batchCLIOptions{
+		outputDir:     *outputDir,
+		userAgent:     *userAgent,
+		retries:       *retries,
+		respectRobots: *respectRobots,
+		robots:        newRobotsChecker(client, *userAgent),
+		chunkTokens:   *chunkTokens,
+		chunkOverlap:  *chunkOverlap,
+		output:        *output,
+	}
+	if *output == "jsonl" {
+		opts.jsonlOut = newNDJSONWriter(os.Stdout)
+	}
+
+	results := make([]batchCLIResult, len(urls))
+	sem := make(chan struct{}, *concurrency)
+	var wg sync.WaitGroup
+
+	for i, u := range urls {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, u string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = convertOneForCLI(client, cfg, u, opts)
+		}(i, u)
+	}
+	wg.Wait()
+
+	summary := batchCLISummary{Total: len(results)}
+	for _, r := range results {
+		if r.Error == "" {
+			summary.Succeeded++
+		} else {
+			summary.Failed++
+		}
+		summary.Results = append(summary.Results, r)
+	}
+
+	if opts.output != "jsonl" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(summary)
+	} else {
+		fmt.Fprintf(os.Stderr, "%d succeeded, %d failed\n", summary.Succeeded, summary.Failed)
+	}
+
+	if summary.Failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// convertOneForCLI fetches and converts a single URL, then either streams
+// its chunks as NDJSON (opts.output == "jsonl") or writes them to disk —
+// one Markdown file per URL when chunking is disabled, one per chunk
+// otherwise. It reports progress on stderr as it goes.
+func convertOneForCLI(client *http.Client, cfg Config, u string, opts batchCLIOptions) batchCLIResult {
+	fmt.Fprintf(os.Stderr, "fetching %s\n", u)
+
+	if opts.respectRobots && !opts.robots.Allowed(u) {
+		fmt.Fprintf(os.Stderr, "skip (robots.txt): %s\n", u)
+		return batchCLIResult{URL: u, Error: "disallowed by robots.txt"}
+	}
+
+	mdText, _, tokenCount, err := fetchAndConvertWithRetry(context.Background(), cfg, client, u, opts.userAgent, opts.retries)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed: %s: %v\n", u, err)
+		return batchCLIResult{URL: u, Error: err.Error()}
+	}
+
+	chunks := chunkMarkdown(mdText, opts.chunkTokens, opts.chunkOverlap, cfg.TokenModel)
+
+	if opts.output == "jsonl" {
+		for _, c := range chunks {
+			opts.jsonlOut.Write(batchChunkLine{URL: u, Chunk: c})
+		}
+		fmt.Fprintf(os.Stderr, "done: %s -> %d chunk(s) (%d tokens)\n", u, len(chunks), tokenCount)
+		return batchCLIResult{URL: u, Chunks: len(chunks), Tokens: tokenCount, Bytes: len(mdText)}
+	}
+
+	if opts.chunkTokens <= 0 {
+		filename := filepath.Join(opts.outputDir, slugifyURL(u)+".md")
+		if err := os.WriteFile(filename, []byte(chunkFileText(chunks[0])), 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write %s: %v\n", filename, err)
+			return batchCLIResult{URL: u, Error: err.Error()}
+		}
+		fmt.Fprintf(os.Stderr, "done: %s -> %s (%d tokens)\n", u, filename, tokenCount)
+		return batchCLIResult{URL: u, File: filename, Tokens: tokenCount, Bytes: len(mdText)}
+	}
+
+	slug := slugifyURL(u)
+	for i, c := range chunks {
+		filename := filepath.Join(opts.outputDir, fmt.Sprintf("%s-%03d.md", slug, i))
+		if err := os.WriteFile(filename, []byte(chunkFileText(c)), 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write %s: %v\n", filename, err)
+			return batchCLIResult{URL: u, Error: err.Error()}
+		}
+	}
+	fmt.Fprintf(os.Stderr, "done: %s -> %d chunk file(s) in %s (%d tokens)\n", u, len(chunks), opts.outputDir, tokenCount)
+	return batchCLIResult{URL: u, File: filepath.Join(opts.outputDir, slug+"-*.md"), Chunks: len(chunks), Tokens: tokenCount, Bytes: len(mdText)}
+}
+
+// chunkFileText renders a chunk as a standalone Markdown document, restoring
+// the "front matter, blank line, body" shape htmlToMarkdown produces, so
+// each chunk file written to disk carries its own provenance.
+func chunkFileText(c Chunk) string {
+	if c.FrontMatter == "" {
+		return c.Text
+	}
+	return c.FrontMatter + "\n\n" + c.Text
+}
+
+// fetchAndConvertWithRetry wraps convertOriginURLAs with exponential
+// backoff and jitter, retrying any failed attempt up to retries times.
+// Errors are not classified: an error status from the origin (such as
+// 404) is retried the same way as a network error.
+func fetchAndConvertWithRetry(ctx context.Context, cfg Config, client *http.Client, originURL, userAgent string, retries int) (string, Metadata, int, error) {
+	var lastErr error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			backoff := time.Duration(1<<uint(attempt-1)) * 200 * time.Millisecond
+			jitter := time.Duration(rand.Int63n(int64(backoff) / 2))
+			time.Sleep(backoff + jitter)
+			fmt.Fprintf(os.Stderr, "retrying (%d/%d): %s\n", attempt, retries, originURL)
+		}
+
+		mdText, meta, tokenCount, err := convertOriginURLAs(ctx, cfg, client, originURL, userAgent)
+		if err == nil {
+			return mdText, meta, tokenCount, nil
+		}
+		lastErr = err
+	}
+	return "", Metadata{}, 0, lastErr
+}
+
+// collectBatchURLs resolves the URL list for a batch run: a --urls-file
+// (one URL per line), a --sitemap, or stdin, in that order of precedence.
+func collectBatchURLs(client *http.Client, urlsFile, sitemap string) ([]string, error) {
+	switch {
+	case urlsFile != "":
+		f, err := os.Open(urlsFile)
+		if err != nil {
+			return nil, fmt.Errorf("open urls file: %w", err)
+		}
+		defer f.Close()
+		return readURLLines(f), nil
+	case sitemap != "":
+		return fetchSitemapPaths(context.Background(), client, sitemap)
+	default:
+		return readURLLines(os.Stdin), nil
+	}
+}
+
+func readURLLines(r interface{ Read([]byte) (int, error) }) []string {
+	var urls []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			urls = append(urls, line)
+		}
+	}
+	return urls
+}
+
+// nonSlugChars matches runs of characters that don't belong in a
+// filename-safe slug.
+var nonSlugChars = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// slugifyURL derives a filesystem-safe name from a URL's host, path, and
+// query, e.g. "https://example.com/blog/my-post" -> "example-com-blog-my-post".
+// Including the host and query (not just the path) avoids different URLs
+// silently overwriting each other's output file in a --urls-file batch
+// that spans multiple hosts, or that hits the same path with different
+// query strings.
+func slugifyURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "index"
+	}
+
+	parts := []string{u.Host}
+	if path := strings.Trim(u.Path, "/"); path != "" {
+		parts = append(parts, path)
+	}
+	if u.RawQuery != "" {
+		parts = append(parts, u.RawQuery)
+	}
+
+	slug := nonSlugChars.ReplaceAllString(strings.Join(parts, "-"), "-")
+	slug = strings.Trim(slug, "-")
+	if slug == "" {
+		return "index"
+	}
+	return slug
+}
@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestSlugifyURL_DerivesFromHostAndPath(t *testing.T) {
+	if got := slugifyURL("https://example.com/blog/my-post"); got != "example-com-blog-my-post" {
+		t.Errorf("expected 'example-com-blog-my-post', got %q", got)
+	}
+}
+
+func TestSlugifyURL_RootPathIsHostOnly(t *testing.T) {
+	if got := slugifyURL("https://example.com/"); got != "example-com" {
+		t.Errorf("expected 'example-com', got %q", got)
+	}
+}
+
+func TestSlugifyURL_InvalidURLIsIndex(t *testing.T) {
+	if got := slugifyURL("://not a url"); got != "index" {
+		t.Errorf("expected 'index' for an invalid URL, got %q", got)
+	}
+}
+
+func TestSlugifyURL_DifferentHostsSamePathDoNotCollide(t *testing.T) {
+	a := slugifyURL("https://example.com/blog/my-post")
+	b := slugifyURL("https://other.com/blog/my-post")
+	if a == b {
+		t.Errorf("expected different hosts sharing a path to slugify differently, both got %q", a)
+	}
+}
+
+func TestSlugifyURL_SameHostDifferentQueryDoNotCollide(t *testing.T) {
+	a := slugifyURL("https://example.com/search?q=foo")
+	b := slugifyURL("https://example.com/search?q=bar")
+	if a == b {
+		t.Errorf("expected different query strings to slugify differently, both got %q", a)
+	}
+}
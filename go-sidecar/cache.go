@@ -0,0 +1,110 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/oliverbe/md-for-varnish/go-sidecar/internal/cache"
+)
+
+// conversionCache bundles the cache Store with a Refresher so the handler
+// can serve stale entries immediately while a revalidation runs in the
+// background (stale-while-revalidate).
+type conversionCache struct {
+	store     cache.Store
+	refresher *cache.Refresher
+	ttl       time.Duration
+}
+
+// newConversionCache wires an LRU store (optionally backed by an
+// on-disk FileStore for persistence across restarts) per cfg.
+func newConversionCache(cfg Config) (*conversionCache, error) {
+	if !cfg.CacheEnabled {
+		return nil, nil
+	}
+
+	var store cache.Store = cache.NewLRU(cfg.CacheCapacity)
+	if cfg.CacheDir != "" {
+		fileStore, err := cache.NewFileStore(cfg.CacheDir)
+		if err != nil {
+			return nil, err
+		}
+		store = &tieredStore{memory: store, disk: fileStore}
+	}
+
+	return &conversionCache{
+		store:     store,
+		refresher: cache.NewRefresher(store, cfg.OriginTimeout),
+		ttl:       cfg.CacheTTL,
+	}, nil
+}
+
+// tieredStore serves from the in-memory LRU first, falling back to the
+// on-disk store (and repopulating the LRU) on a miss.
+type tieredStore struct {
+	memory cache.Store
+	disk   cache.Store
+}
+
+func (t *tieredStore) Get(key string) (cache.Entry, bool) {
+	if entry, ok := t.memory.Get(key); ok {
+		return entry, true
+	}
+	entry, ok := t.disk.Get(key)
+	if ok {
+		t.memory.Set(key, entry)
+	}
+	return entry, ok
+}
+
+func (t *tieredStore) Set(key string, entry cache.Entry) {
+	t.memory.Set(key, entry)
+	t.disk.Set(key, entry)
+}
+
+// entryFromConversion builds a cache.Entry from a freshly converted page.
+func entryFromConversion(mdText string, meta Metadata, tokens int, originETag, originLastModified string, ttl time.Duration) cache.Entry {
+	now := time.Now()
+	return cache.Entry{
+		Markdown: mdText,
+		Metadata: map[string]string{
+			"title":       meta.Title,
+			"description": meta.Description,
+			"author":      meta.Author,
+			"keywords":    meta.Keywords,
+			"image":       meta.Image,
+		},
+		Tokens:             tokens,
+		OriginETag:         originETag,
+		OriginLastModified: originLastModified,
+		FetchedAt:          now,
+		ExpiresAt:          now.Add(ttl),
+	}
+}
+
+// etagFor derives a weak ETag for the cached markdown body so clients and
+// Varnish can make conditional requests against our representation,
+// independent of whatever ETag (if any) the origin sent.
+func etagFor(markdown string) string {
+	sum := sha256.Sum256([]byte(markdown))
+	return `W/"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// writeConditionalHeaders sets ETag/Last-Modified and reports whether the
+// request's conditional headers mean we should answer 304 Not Modified.
+func writeConditionalHeaders(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time) bool {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" && inm == etag {
+		return true
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		if t, err := http.ParseTime(ims); err == nil && !lastModified.After(t) {
+			return true
+		}
+	}
+	return false
+}
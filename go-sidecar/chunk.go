@@ -0,0 +1,316 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Chunk is one token-budgeted slice of a converted Markdown document,
+// carrying enough provenance for a downstream vector store to cite it
+// back to its source page.
+type Chunk struct {
+	Text        string `json:"text"`
+	Breadcrumb  string `json:"breadcrumb,omitempty"`
+	FrontMatter string `json:"front_matter,omitempty"`
+	// StartByte and EndByte bound this chunk's newly-introduced content in
+	// the original document. When overlap > 0, Text is prefixed with the
+	// tail of the previous chunk, so [StartByte:EndByte] does not cover
+	// the overlap text itself — only the chunk's own span.
+	StartByte int `json:"start_byte"`
+	EndByte   int `json:"end_byte"`
+	Tokens    int `json:"tokens"`
+}
+
+// chunkUnit is an indivisible (or no-longer-divisible) span of md body
+// text carried through splitting and packing, before it's grouped into
+// Chunks.
+type chunkUnit struct {
+	text       string
+	start, end int // byte offsets into the original md, not body
+	breadcrumb string
+}
+
+// headingLine matches an ATX heading ("# Title" .. "###### Title"), the
+// only heading style this sidecar's renderers emit (see renderer.go's
+// WithHeadingStyle("atx")).
+var headingLine = regexp.MustCompile(`(?m)^(#{1,6})[ \t]+(.+?)[ \t]*$`)
+
+// blankLines splits a unit into paragraphs.
+var blankLines = regexp.MustCompile(`\n{2,}`)
+
+// sentenceEnd splits a paragraph into sentences, breaking after
+// sentence-ending punctuation followed by whitespace.
+var sentenceEnd = regexp.MustCompile(`[.!?]["')\]]?\s+`)
+
+// chunkMarkdown splits md into chunks of at most maxTokens tokens each
+// (measured with tokenModel's tiktoken encoding), breaking along semantic
+// boundaries — headings first, then paragraphs, then sentences — and
+// packing sections greedily so a chunk is filled before starting the
+// next. The last overlap tokens of a chunk are repeated at the start of
+// the next one, so a retriever that returns one chunk still has some of
+// the surrounding context. Each Chunk carries its heading breadcrumb
+// ("H1 > H2 > H3"), its byte range in md, and any leading front-matter
+// block. maxTokens <= 0 returns the whole document as a single chunk.
+func chunkMarkdown(md string, maxTokens, overlap int, tokenModel string) []Chunk {
+	frontMatter, body, bodyOffset := splitFrontMatter(md)
+
+	if maxTokens <= 0 {
+		return []Chunk{{
+			Text:        strings.TrimSpace(body),
+			FrontMatter: frontMatter,
+			StartByte:   bodyOffset,
+			EndByte:     len(md),
+			Tokens:      countTokens(body, tokenModel),
+		}}
+	}
+
+	units := splitIntoSections(body, bodyOffset)
+
+	var atoms []chunkUnit
+	for _, u := range units {
+		atoms = append(atoms, fitUnit(u, maxTokens, tokenModel)...)
+	}
+
+	return packUnits(atoms, frontMatter, maxTokens, overlap, tokenModel)
+}
+
+// splitFrontMatter detects and strips a leading front-matter block in any
+// of the formats buildFrontMatterAs can produce (yaml "---", toml "+++",
+// a raw json object, or org-mode "#+KEY:" lines), returning it separately
+// along with the body and the body's starting byte offset in md.
+func splitFrontMatter(md string) (frontMatter, body string, bodyOffset int) {
+	switch {
+	case strings.HasPrefix(md, "---\n"):
+		if end := strings.Index(md[4:], "\n---"); end != -1 {
+			blockEnd := 4 + end + len("\n---")
+			body, bodyOffset := skipSeparator(md, blockEnd)
+			return md[:blockEnd], body, bodyOffset
+		}
+	case strings.HasPrefix(md, "+++\n"):
+		if end := strings.Index(md[4:], "\n+++"); end != -1 {
+			blockEnd := 4 + end + len("\n+++")
+			body, bodyOffset := skipSeparator(md, blockEnd)
+			return md[:blockEnd], body, bodyOffset
+		}
+	case strings.HasPrefix(md, "{"):
+		if end := strings.Index(md, "\n}"); end != -1 {
+			blockEnd := end + len("\n}")
+			body, bodyOffset := skipSeparator(md, blockEnd)
+			return md[:blockEnd], body, bodyOffset
+		}
+	case strings.HasPrefix(md, "#+"):
+		lines := strings.SplitAfter(md, "\n")
+		blockEnd := 0
+		for _, line := range lines {
+			if !strings.HasPrefix(line, "#+") {
+				break
+			}
+			blockEnd += len(line)
+		}
+		if blockEnd > 0 {
+			body, bodyOffset := skipSeparator(md, blockEnd)
+			return strings.TrimRight(md[:blockEnd], "\n"), body, bodyOffset
+		}
+	}
+	return "", md, 0
+}
+
+// skipSeparator returns the body following a front-matter block ending
+// at blockEnd, along with its byte offset in the original document,
+// skipping the blank line buildFrontMatterAs inserts between the two.
+func skipSeparator(md string, blockEnd int) (string, int) {
+	rest := md[blockEnd:]
+	trimmed := strings.TrimLeft(rest, "\n")
+	offset := blockEnd + (len(rest) - len(trimmed))
+	return trimmed, offset
+}
+
+// splitIntoSections splits body into one chunkUnit per heading (plus a
+// leading preamble unit for any content before the first heading),
+// tracking each unit's "H1 > H2 > H3" breadcrumb as headings are seen.
+func splitIntoSections(body string, bodyOffset int) []chunkUnit {
+	matches := headingLine.FindAllStringSubmatchIndex(body, -1)
+	if len(matches) == 0 {
+		if strings.TrimSpace(body) == "" {
+			return nil
+		}
+		return []chunkUnit{{text: body, start: bodyOffset, end: bodyOffset + len(body)}}
+	}
+
+	var units []chunkUnit
+	if matches[0][0] > 0 && strings.TrimSpace(body[:matches[0][0]]) != "" {
+		units = append(units, chunkUnit{text: body[:matches[0][0]], start: bodyOffset, end: bodyOffset + matches[0][0]})
+	}
+
+	var breadcrumbStack [6]string
+	for i, m := range matches {
+		level := m[3] - m[2]
+		heading := body[m[4]:m[5]]
+
+		breadcrumbStack[level-1] = heading
+		for j := level; j < 6; j++ {
+			breadcrumbStack[j] = ""
+		}
+		var parts []string
+		for _, b := range breadcrumbStack {
+			if b != "" {
+				parts = append(parts, b)
+			}
+		}
+
+		start := m[0]
+		end := len(body)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		units = append(units, chunkUnit{
+			text:       body[start:end],
+			start:      bodyOffset + start,
+			end:        bodyOffset + end,
+			breadcrumb: strings.Join(parts, " > "),
+		})
+	}
+	return units
+}
+
+// fitUnit returns u unchanged if it already fits within maxTokens,
+// otherwise recursively splits it on blank lines (paragraphs) and then
+// sentence boundaries until every piece fits (or can't be split further).
+func fitUnit(u chunkUnit, maxTokens int, tokenModel string) []chunkUnit {
+	if countTokens(u.text, tokenModel) <= maxTokens {
+		return []chunkUnit{u}
+	}
+	return splitUnit(u, maxTokens, tokenModel, blankLines, func(u chunkUnit) []chunkUnit {
+		return splitUnit(u, maxTokens, tokenModel, sentenceEnd, func(u chunkUnit) []chunkUnit {
+			return []chunkUnit{u} // no finer boundary left; hand back as-is
+		})
+	})
+}
+
+// splitUnit breaks u apart at sep's matches, recursing into next for any
+// resulting piece that still exceeds maxTokens.
+func splitUnit(u chunkUnit, maxTokens int, tokenModel string, sep *regexp.Regexp, next func(chunkUnit) []chunkUnit) []chunkUnit {
+	matches := sep.FindAllStringIndex(u.text, -1)
+	if len(matches) == 0 {
+		return next(u)
+	}
+
+	var pieces []chunkUnit
+	prev := 0
+	for _, m := range matches {
+		pieces = append(pieces, chunkUnit{text: u.text[prev:m[1]], start: u.start + prev, end: u.start + m[1], breadcrumb: u.breadcrumb})
+		prev = m[1]
+	}
+	if prev < len(u.text) {
+		pieces = append(pieces, chunkUnit{text: u.text[prev:], start: u.start + prev, end: u.end, breadcrumb: u.breadcrumb})
+	}
+
+	var fitted []chunkUnit
+	for _, p := range pieces {
+		if strings.TrimSpace(p.text) == "" {
+			continue
+		}
+		if countTokens(p.text, tokenModel) <= maxTokens {
+			fitted = append(fitted, p)
+		} else {
+			fitted = append(fitted, next(p)...)
+		}
+	}
+	return fitted
+}
+
+// packUnits greedily packs atoms into chunks of at most maxTokens tokens,
+// carrying the last `overlap` tokens of each chunk into the start of the
+// next one. Whether an atom still fits is decided by re-measuring the
+// actual joined text (atoms are glued together with "\n\n" in the result),
+// not by summing each atom's token count in isolation — the latter ignores
+// the separator's own token cost and can under-count by several tokens per
+// join, letting chunks creep past maxTokens. If the carried-over overlap
+// itself leaves no room for the next atom, the overlap is dropped for
+// that chunk rather than exceeding maxTokens — staying within budget
+// takes priority over preserving the requested overlap.
+func packUnits(atoms []chunkUnit, frontMatter string, maxTokens, overlap int, tokenModel string) []Chunk {
+	var chunks []Chunk
+	var current string
+	var breadcrumb string
+	var start, end int
+	freshChunk := true
+
+	flush := func() {
+		if current == "" {
+			return
+		}
+		chunks = append(chunks, Chunk{
+			Text:        strings.TrimSpace(current),
+			Breadcrumb:  breadcrumb,
+			FrontMatter: frontMatter,
+			StartByte:   start,
+			EndByte:     end,
+			Tokens:      countTokens(current, tokenModel),
+		})
+	}
+
+	for _, a := range atoms {
+		candidate := a.text
+		if current != "" {
+			candidate = current + "\n\n" + a.text
+		}
+
+		if current != "" && countTokens(candidate, tokenModel) > maxTokens {
+			flush()
+
+			current = tailTokens(current, overlap, tokenModel)
+			freshChunk = true
+
+			candidate = a.text
+			if current != "" {
+				candidate = current + "\n\n" + a.text
+				if countTokens(candidate, tokenModel) > maxTokens {
+					// The carried-over overlap plus this atom alone
+					// already exceeds maxTokens (a large overlap relative
+					// to maxTokens). fitUnit already guarantees a.text
+					// alone fits, so drop the overlap rather than ship an
+					// over-budget chunk.
+					current = ""
+					candidate = a.text
+				}
+			}
+		}
+
+		if freshChunk {
+			start = a.start
+			freshChunk = false
+		}
+		current = candidate
+		breadcrumb = a.breadcrumb
+		end = a.end
+	}
+	flush()
+
+	return chunks
+}
+
+// tailTokens returns the suffix of text whose token count is at most n
+// (measured with tokenModel), for the overlap carried into the next
+// chunk. n <= 0 returns "".
+func tailTokens(text string, n int, tokenModel string) string {
+	if n <= 0 || text == "" {
+		return ""
+	}
+
+	enc, err := tiktokenEncodingFor(tokenModel)
+	if err != nil {
+		// Fallback: ~4 chars per token, matching countTokens' own fallback.
+		maxChars := n * 4
+		if maxChars >= len(text) {
+			return text
+		}
+		return text[len(text)-maxChars:]
+	}
+
+	ids := enc.Encode(text, nil, nil)
+	if len(ids) <= n {
+		return text
+	}
+	return enc.Decode(ids[len(ids)-n:])
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitFrontMatter_YAML(t *testing.T) {
+	md := "---\ntitle: Hello\n---\n\n# Heading\n\nBody text."
+	fm, body, offset := splitFrontMatter(md)
+	if !strings.HasPrefix(fm, "---\n") || !strings.Contains(fm, "title: Hello") {
+		t.Errorf("unexpected front matter: %q", fm)
+	}
+	if !strings.HasPrefix(body, "# Heading") {
+		t.Errorf("unexpected body: %q", body)
+	}
+	if md[offset:] != body {
+		t.Errorf("offset %d does not point at body start", offset)
+	}
+}
+
+func TestSplitFrontMatter_TOML(t *testing.T) {
+	md := "+++\ntitle = \"Hello\"\n+++\n\nBody."
+	fm, body, _ := splitFrontMatter(md)
+	if !strings.HasPrefix(fm, "+++\n") {
+		t.Errorf("unexpected front matter: %q", fm)
+	}
+	if body != "Body." {
+		t.Errorf("unexpected body: %q", body)
+	}
+}
+
+func TestSplitFrontMatter_JSON(t *testing.T) {
+	md := "{\n  \"title\": \"Hello\"\n}\n\nBody."
+	fm, body, _ := splitFrontMatter(md)
+	if !strings.HasPrefix(fm, "{") || !strings.HasSuffix(fm, "}") {
+		t.Errorf("unexpected front matter: %q", fm)
+	}
+	if body != "Body." {
+		t.Errorf("unexpected body: %q", body)
+	}
+}
+
+func TestSplitFrontMatter_Org(t *testing.T) {
+	md := "#+TITLE: Hello\n#+AUTHOR: Jane\n\nBody."
+	fm, body, _ := splitFrontMatter(md)
+	if fm != "#+TITLE: Hello\n#+AUTHOR: Jane" {
+		t.Errorf("unexpected front matter: %q", fm)
+	}
+	if body != "Body." {
+		t.Errorf("unexpected body: %q", body)
+	}
+}
+
+func TestSplitFrontMatter_NoneReturnsWholeDocAsBody(t *testing.T) {
+	md := "# Heading\n\nBody."
+	fm, body, offset := splitFrontMatter(md)
+	if fm != "" {
+		t.Errorf("expected no front matter, got %q", fm)
+	}
+	if body != md || offset != 0 {
+		t.Errorf("expected body to be the whole document at offset 0, got offset %d body %q", offset, body)
+	}
+}
+
+func TestChunkMarkdown_ZeroMaxTokensReturnsSingleChunk(t *testing.T) {
+	md := "---\ntitle: Hello\n---\n\n# Heading\n\nBody text."
+	chunks := chunkMarkdown(md, 0, 0, "cl100k_base")
+	if len(chunks) != 1 {
+		t.Fatalf("expected a single chunk, got %d", len(chunks))
+	}
+	if !strings.Contains(chunks[0].FrontMatter, "title: Hello") {
+		t.Errorf("expected front matter to be carried, got %q", chunks[0].FrontMatter)
+	}
+	if !strings.Contains(chunks[0].Text, "Body text.") {
+		t.Errorf("expected the body in the single chunk, got %q", chunks[0].Text)
+	}
+}
+
+func TestChunkMarkdown_SplitsOnHeadingsWithBreadcrumb(t *testing.T) {
+	md := "# Guide\n\n## Setup\n\n" + strings.Repeat("Install the package and configure it. ", 80) +
+		"\n\n## Usage\n\n" + strings.Repeat("Call the function with your input. ", 80)
+
+	chunks := chunkMarkdown(md, 60, 0, "cl100k_base")
+	if len(chunks) < 2 {
+		t.Fatalf("expected the long sections to be split into multiple chunks, got %d", len(chunks))
+	}
+
+	var sawSetup, sawUsage bool
+	for _, c := range chunks {
+		if strings.Contains(c.Breadcrumb, "Setup") {
+			sawSetup = true
+		}
+		if strings.Contains(c.Breadcrumb, "Usage") {
+			sawUsage = true
+		}
+		if !strings.HasPrefix(c.Breadcrumb, "Guide") {
+			t.Errorf("expected every breadcrumb to start with the H1, got %q", c.Breadcrumb)
+		}
+		if c.Tokens > 60 && strings.Count(c.Text, " ") > 1 {
+			t.Errorf("chunk exceeds maxTokens and has more than one word to split further: %q", c.Text)
+		}
+	}
+	if !sawSetup || !sawUsage {
+		t.Error("expected both H2 sections to appear in some chunk's breadcrumb")
+	}
+}
+
+func TestChunkMarkdown_ByteOffsetsPointBackIntoDocument(t *testing.T) {
+	md := "# Heading\n\nFirst paragraph.\n\nSecond paragraph."
+	chunks := chunkMarkdown(md, 1000, 0, "cl100k_base")
+	for _, c := range chunks {
+		if c.StartByte < 0 || c.EndByte > len(md) || c.StartByte > c.EndByte {
+			t.Fatalf("chunk offsets [%d:%d] out of bounds for document of length %d", c.StartByte, c.EndByte, len(md))
+		}
+	}
+}
+
+func TestChunkMarkdown_OverlapCarriesTailIntoNextChunk(t *testing.T) {
+	md := "# Guide\n\n## Setup\n\n" + strings.Repeat("Install the package and configure it carefully. ", 60) +
+		"\n\n## Usage\n\n" + strings.Repeat("Call the function with your input data. ", 60)
+
+	withOverlap := chunkMarkdown(md, 50, 20, "cl100k_base")
+	withoutOverlap := chunkMarkdown(md, 50, 0, "cl100k_base")
+
+	if len(withOverlap) < 2 {
+		t.Fatalf("expected multiple chunks, got %d", len(withOverlap))
+	}
+
+	overlapTail := lastWords(withOverlap[0].Text, 3)
+	if !strings.Contains(withOverlap[1].Text, overlapTail) {
+		t.Errorf("expected chunk 2 to repeat the tail of chunk 1 (%q), got %q", overlapTail, withOverlap[1].Text)
+	}
+	if withOverlap[1].Text == withoutOverlap[1].Text {
+		t.Error("expected overlap to change the following chunk's text")
+	}
+}
+
+func TestChunkMarkdown_LargeOverlapStaysWithinMaxTokens(t *testing.T) {
+	var sections []string
+	for i := 0; i < 20; i++ {
+		sections = append(sections, "## Heading "+strings.Repeat("x", i%3)+"\n"+
+			strings.Repeat("Some paragraph text for this section. ", 6))
+	}
+	md := strings.Join(sections, "\n\n")
+
+	// A bogus model name forces countTokens/tailTokens onto their
+	// deterministic len(text)/4 fallback, so this doesn't depend on
+	// network access to fetch a real tiktoken encoding.
+	chunks := chunkMarkdown(md, 50, 45, "bogus-model-forces-fallback")
+	for i, c := range chunks {
+		if c.Tokens > 50 {
+			t.Errorf("chunk %d has %d tokens, want <= maxTokens (50): %q", i, c.Tokens, c.Text)
+		}
+	}
+}
+
+// lastWords returns the last n space-separated words of s, for asserting
+// overlap without depending on exact token boundaries.
+func lastWords(s string, n int) string {
+	words := strings.Fields(s)
+	if len(words) <= n {
+		return strings.Join(words, " ")
+	}
+	return strings.Join(words[len(words)-n:], " ")
+}
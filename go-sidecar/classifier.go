@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// botClass tags an incoming request so the handler can apply a different
+// caching and rate-limit policy to bots than to humans or API clients,
+// mirroring gddo-server's humanRequest/robotRequest/apiRequest split.
+type botClass string
+
+const (
+	botClassGPT        botClass = "gptbot"
+	botClassClaude     botClass = "claudebot"
+	botClassPerplexity botClass = "perplexitybot"
+	botClassGeneric    botClass = "generic"
+	botClassHuman      botClass = "human"
+)
+
+// botUserAgents maps a case-insensitive User-Agent substring to its
+// class. Order matters: named bots are matched before the generic
+// crawler fallback.
+var botUserAgents = []struct {
+	substr string
+	class  botClass
+}{
+	{"gptbot", botClassGPT},
+	{"chatgpt-user", botClassGPT},
+	{"claudebot", botClassClaude},
+	{"claude-web", botClassClaude},
+	{"anthropic-ai", botClassClaude},
+	{"perplexitybot", botClassPerplexity},
+	{"bot", botClassGeneric},
+	{"spider", botClassGeneric},
+	{"crawler", botClassGeneric},
+}
+
+// classifyRequest tags r with a botClass based on its User-Agent.
+func classifyRequest(r *http.Request) botClass {
+	ua := strings.ToLower(r.Header.Get("User-Agent"))
+	for _, entry := range botUserAgents {
+		if strings.Contains(ua, entry.substr) {
+			return entry.class
+		}
+	}
+	return botClassHuman
+}
+
+// isBot reports whether the class is any of the known bot classes.
+func (c botClass) isBot() bool {
+	return c != botClassHuman
+}
+
+// cacheControlFor returns the Cache-Control header to send for a given
+// request class. Bots are served long-lived responses, since a stale
+// crawl is cheap and re-crawling is expensive; humans and API clients
+// get a shorter max-age so edits show up promptly, and can always force
+// a fresh conversion with ?refresh=1.
+func cacheControlFor(class botClass) string {
+	if class.isBot() {
+		return "public, max-age=3600"
+	}
+	return "public, max-age=300"
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClassifyRequest_KnownBots(t *testing.T) {
+	cases := map[string]botClass{
+		"Mozilla/5.0 AppleWebKit (compatible; GPTBot/1.0)": botClassGPT,
+		"ChatGPT-User/1.0": botClassGPT,
+		"Mozilla/5.0 (compatible; ClaudeBot/1.0; +https://x)": botClassClaude,
+		"anthropic-ai": botClassClaude,
+		"Mozilla/5.0 (compatible; PerplexityBot/1.0)": botClassPerplexity,
+		"SomeRandomCrawler/2.0":                       botClassGeneric,
+		"Googlebot-Image/1.0 spider":                  botClassGeneric,
+	}
+	for ua, want := range cases {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("User-Agent", ua)
+		if got := classifyRequest(req); got != want {
+			t.Errorf("classifyRequest(%q) = %q, want %q", ua, got, want)
+		}
+	}
+}
+
+func TestClassifyRequest_Human(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15) AppleWebKit/605.1.15")
+	if got := classifyRequest(req); got != botClassHuman {
+		t.Errorf("expected human classification, got %q", got)
+	}
+}
+
+func TestBotClass_IsBot(t *testing.T) {
+	if botClassHuman.isBot() {
+		t.Error("human should not be classified as a bot")
+	}
+	if !botClassGeneric.isBot() {
+		t.Error("generic bot class should be classified as a bot")
+	}
+}
+
+func TestCacheControlFor(t *testing.T) {
+	if got := cacheControlFor(botClassHuman); got != "public, max-age=300" {
+		t.Errorf("expected short max-age for humans, got %q", got)
+	}
+	if got := cacheControlFor(botClassGPT); got != "public, max-age=3600" {
+		t.Errorf("expected long max-age for bots, got %q", got)
+	}
+}
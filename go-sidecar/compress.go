@@ -0,0 +1,110 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// gzipWriterPool reuses gzip.Writer instances across requests to avoid a
+// per-request allocation for the most common encoding.
+var gzipWriterPool = sync.Pool{
+	New: func() any { return gzip.NewWriter(io.Discard) },
+}
+
+// withCompression wraps next, transparently encoding the response body
+// with gzip, brotli, or zstd according to Accept-Encoding. It is a plain
+// http.Handler wrapper (no framework dependency) so it can wrap /healthz
+// and any future endpoint the same way it wraps the convert handler.
+func withCompression(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		switch preferredEncoding(r.Header.Get("Accept-Encoding")) {
+		case "br":
+			bw := brotli.NewWriterLevel(w, brotli.DefaultCompression)
+			cw := &compressResponseWriter{ResponseWriter: w, compressor: bw, writer: bw, encoding: "br"}
+			defer cw.Close()
+			next.ServeHTTP(cw, r)
+		case "zstd":
+			zw, err := zstd.NewWriter(w)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			cw := &compressResponseWriter{ResponseWriter: w, compressor: zw, writer: zw, encoding: "zstd"}
+			defer cw.Close()
+			next.ServeHTTP(cw, r)
+		case "gzip":
+			gw := gzipWriterPool.Get().(*gzip.Writer)
+			gw.Reset(w)
+			defer gzipWriterPool.Put(gw)
+			cw := &compressResponseWriter{ResponseWriter: w, compressor: gw, writer: gw, encoding: "gzip"}
+			defer cw.Close()
+			next.ServeHTTP(cw, r)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// preferredEncoding picks the best encoding the client accepts, preferring
+// brotli and zstd over gzip for their better ratio when both are offered.
+func preferredEncoding(acceptEncoding string) string {
+	for _, enc := range []string{"br", "zstd", "gzip"} {
+		if strings.Contains(acceptEncoding, enc) {
+			return enc
+		}
+	}
+	return ""
+}
+
+// compressResponseWriter wraps an http.ResponseWriter, routing Write
+// calls through a compressing writer unless the wrapped handler already
+// set Content-Encoding itself (the non-HTML passthrough path forwards the
+// origin's own encoding, which must never be compressed again).
+type compressResponseWriter struct {
+	http.ResponseWriter
+	compressor  io.Closer
+	writer      io.Writer
+	encoding    string
+	wroteHeader bool
+	passthrough bool
+}
+
+func (c *compressResponseWriter) WriteHeader(status int) {
+	if c.Header().Get("Content-Encoding") != "" {
+		c.writer = c.ResponseWriter
+		c.passthrough = true
+	} else {
+		c.Header().Set("Content-Encoding", c.encoding)
+		c.Header().Del("Content-Length")
+	}
+	c.wroteHeader = true
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *compressResponseWriter) Write(b []byte) (int, error) {
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
+	return c.writer.Write(b)
+}
+
+// Close flushes and closes the underlying compressor, unless the wrapped
+// handler set its own Content-Encoding and WriteHeader routed writes
+// straight to the raw ResponseWriter instead. The compressor still wraps
+// that raw ResponseWriter directly, so closing an unused one would append
+// its (e.g. gzip) trailer bytes onto a body that was already written
+// through uncompressed.
+func (c *compressResponseWriter) Close() error {
+	if c.passthrough {
+		return nil
+	}
+	return c.compressor.Close()
+}
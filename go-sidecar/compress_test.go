@@ -0,0 +1,136 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestWithCompression_GzipsWhenAccepted(t *testing.T) {
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		io.WriteString(w, "# Hello World")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/page", nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	w := httptest.NewRecorder()
+
+	withCompression(inner).ServeHTTP(w, req)
+
+	resp := w.Result()
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected gzip Content-Encoding, got %q", resp.Header.Get("Content-Encoding"))
+	}
+
+	gr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to open gzip reader: %v", err)
+	}
+	decoded, err := io.ReadAll(gr)
+	if err != nil {
+		t.Fatalf("failed to read gzip body: %v", err)
+	}
+	if string(decoded) != "# Hello World" {
+		t.Errorf("expected decoded body '# Hello World', got %q", decoded)
+	}
+}
+
+func TestWithCompression_BrotliWhenPreferred(t *testing.T) {
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "# Hello World")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/page", nil)
+	req.Header.Set("Accept-Encoding", "gzip, br")
+	w := httptest.NewRecorder()
+
+	withCompression(inner).ServeHTTP(w, req)
+
+	resp := w.Result()
+	if resp.Header.Get("Content-Encoding") != "br" {
+		t.Fatalf("expected br Content-Encoding (brotli preferred over gzip), got %q", resp.Header.Get("Content-Encoding"))
+	}
+
+	decoded, err := io.ReadAll(brotli.NewReader(resp.Body))
+	if err != nil {
+		t.Fatalf("failed to read brotli body: %v", err)
+	}
+	if string(decoded) != "# Hello World" {
+		t.Errorf("expected decoded body '# Hello World', got %q", decoded)
+	}
+}
+
+func TestWithCompression_NoAcceptEncodingPassesThrough(t *testing.T) {
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "# Hello World")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/page", nil)
+	w := httptest.NewRecorder()
+
+	withCompression(inner).ServeHTTP(w, req)
+
+	resp := w.Result()
+	if enc := resp.Header.Get("Content-Encoding"); enc != "" {
+		t.Errorf("expected no Content-Encoding, got %q", enc)
+	}
+	if w.Body.String() != "# Hello World" {
+		t.Errorf("expected plain body, got %q", w.Body.String())
+	}
+}
+
+func TestWithCompression_SkipsDoubleEncodingPassthroughResponses(t *testing.T) {
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Mimics the non-HTML passthrough path forwarding the origin's own encoding.
+		w.Header().Set("Content-Encoding", "gzip")
+		io.WriteString(w, "already-encoded-bytes")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/page", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+
+	withCompression(inner).ServeHTTP(w, req)
+
+	if w.Body.String() != "already-encoded-bytes" {
+		t.Errorf("expected upstream-encoded body to pass through untouched, got %q", w.Body.String())
+	}
+}
+
+func TestWithCompression_SetsVaryHeader(t *testing.T) {
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "body")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/page", nil)
+	w := httptest.NewRecorder()
+
+	withCompression(inner).ServeHTTP(w, req)
+
+	if !strings.Contains(w.Result().Header.Get("Vary"), "Accept-Encoding") {
+		t.Error("expected Vary: Accept-Encoding")
+	}
+}
+
+func TestWithCompression_PreservesInnerHandlerVaryValues(t *testing.T) {
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+		io.WriteString(w, "body")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/page", nil)
+	w := httptest.NewRecorder()
+
+	withCompression(inner).ServeHTTP(w, req)
+
+	vary := w.Result().Header.Values("Vary")
+	if !strings.Contains(strings.Join(vary, ","), "Accept-Encoding") || !strings.Contains(strings.Join(vary, ","), "Accept") {
+		t.Errorf("expected both Vary: Accept-Encoding and Vary: Accept to survive, got %v", vary)
+	}
+}
@@ -1,24 +1,23 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"strings"
+	"sync"
 
-	"github.com/JohannesKaufmann/html-to-markdown/v2/converter"
-	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/base"
-	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/commonmark"
-	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/table"
 	"github.com/PuerkitoBio/goquery"
 	tiktoken "github.com/pkoukk/tiktoken-go"
+	"github.com/yuin/goldmark"
 )
 
 // Metadata holds page metadata extracted from <head>.
 type Metadata struct {
-	Title       string
-	Description string
-	Author      string
-	Keywords    string
-	Image       string
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Author      string `json:"author,omitempty"`
+	Keywords    string `json:"keywords,omitempty"`
+	Image       string `json:"image,omitempty"`
 }
 
 // stripSelectors are CSS selectors for non-content elements to remove.
@@ -93,8 +92,17 @@ func removeImages(sel *goquery.Selection) {
 	sel.Find("img").Remove()
 }
 
-// htmlToMarkdown converts an HTML document to clean Markdown with YAML front matter.
-func htmlToMarkdown(html string, extraSelectors []string) (string, Metadata) {
+// htmlToMarkdown converts an HTML document to clean Markdown with front
+// matter. extractor selects how the content root is isolated; a nil
+// extractor falls back to the original main/article/#content/.content/body
+// heuristic. renderer converts the isolated content HTML to Markdown; a
+// nil renderer falls back to HTMLToMarkdownRenderer with every extension
+// enabled. frontMatterFormat selects the front matter serialization
+// ("yaml", "toml", "json", "org", or "none"); an empty value defaults to
+// "yaml". pageURL is the page's own URL, used to resolve relative image
+// src attributes when imgOpts.Mode is not "strip"; imgOpts.Mode defaults
+// to "strip" (the sidecar's original behavior) for an empty value.
+func htmlToMarkdown(html string, extraSelectors []string, extractor ContentExtractor, renderer Renderer, frontMatterFormat string, pageURL string, imgOpts ImageOptions) (string, Metadata) {
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
 	if err != nil {
 		return "", Metadata{}
@@ -104,13 +112,16 @@ func htmlToMarkdown(html string, extraSelectors []string) (string, Metadata) {
 	meta := extractMetadata(doc)
 
 	// 2. Isolate content root
-	contentRoot := findContentRoot(doc)
+	if extractor == nil {
+		extractor = selectorsExtractor{}
+	}
+	contentRoot := extractor.Extract(doc)
 
 	// 3. Strip non-content elements
 	stripNonContent(contentRoot, extraSelectors)
 
-	// 4. Remove images (useless for agents)
-	removeImages(contentRoot)
+	// 4. Handle images per imgOpts.Mode (strip/keep/inline/proxy)
+	processImages(doc, contentRoot, pageURL, imgOpts)
 
 	// 5. Get inner HTML of content root
 	contentHTML, err := contentRoot.Html()
@@ -119,18 +130,10 @@ func htmlToMarkdown(html string, extraSelectors []string) (string, Metadata) {
 	}
 
 	// 6. Convert to Markdown
-	conv := converter.NewConverter(
-		converter.WithPlugins(
-			base.NewBasePlugin(),
-			commonmark.NewCommonmarkPlugin(
-				commonmark.WithHeadingStyle("atx"),
-				commonmark.WithBulletListMarker("-"),
-			),
-			table.NewTablePlugin(),
-		),
-	)
-
-	mdBody, err := conv.ConvertString(contentHTML)
+	if renderer == nil {
+		renderer = HTMLToMarkdownRenderer{opts: defaultRenderOptions()}
+	}
+	mdBody, err := renderer.Render(contentHTML)
 	if err != nil {
 		return "", meta
 	}
@@ -140,7 +143,7 @@ func htmlToMarkdown(html string, extraSelectors []string) (string, Metadata) {
 	mdBody = strings.TrimSpace(mdBody)
 
 	// 8. Build front matter
-	if fm := buildFrontMatter(meta); fm != "" {
+	if fm := buildFrontMatterAs(meta, frontMatterFormat); fm != "" {
 		mdBody = fm + "\n\n" + mdBody
 	}
 
@@ -166,43 +169,43 @@ func cleanBlankLines(text string) string {
 	return strings.Join(cleaned, "\n")
 }
 
-// buildFrontMatter creates YAML front matter from metadata.
-func buildFrontMatter(meta Metadata) string {
-	var lines []string
-
-	if meta.Title != "" {
-		lines = append(lines, fmt.Sprintf(`title: "%s"`, escapeYAML(meta.Title)))
-	}
-	if meta.Description != "" {
-		lines = append(lines, fmt.Sprintf(`description: "%s"`, escapeYAML(meta.Description)))
-	}
-	if meta.Author != "" {
-		lines = append(lines, fmt.Sprintf(`author: "%s"`, escapeYAML(meta.Author)))
-	}
-	if meta.Keywords != "" {
-		lines = append(lines, fmt.Sprintf(`keywords: "%s"`, escapeYAML(meta.Keywords)))
-	}
-	if meta.Image != "" {
-		lines = append(lines, fmt.Sprintf(`image: "%s"`, escapeYAML(meta.Image)))
-	}
-
-	if len(lines) == 0 {
-		return ""
+// renderMarkdownHTML rerenders converted Markdown back to HTML (via goldmark)
+// for clients that requested the text/html representation.
+func renderMarkdownHTML(md string) (string, error) {
+	var buf bytes.Buffer
+	if err := goldmark.Convert([]byte(md), &buf); err != nil {
+		return "", fmt.Errorf("render markdown to html: %w", err)
 	}
-
-	return "---\n" + strings.Join(lines, "\n") + "\n---"
-}
-
-func escapeYAML(s string) string {
-	return strings.ReplaceAll(s, `"`, `\"`)
+	return buf.String(), nil
 }
 
 // countTokens estimates token count using tiktoken cl100k_base.
 func countTokens(text string, model string) int {
-	enc, err := tiktoken.GetEncoding(model)
+	enc, err := tiktokenEncodingFor(model)
 	if err != nil {
 		// Fallback: ~4 chars per token
 		return len(text) / 4
 	}
 	return len(enc.Encode(text, nil, nil))
 }
+
+// tiktokenEncodings caches the *tiktoken.Tiktoken for each model name.
+// tiktoken.GetEncoding fetches its BPE vocab over the network with no
+// caching of its own, and countTokens/tailTokens can be called dozens of
+// times per document (once per chunk, atom, and overlap), so an uncached
+// lookup here turns chunking into a per-atom network round trip.
+var tiktokenEncodings sync.Map
+
+// tiktokenEncodingFor returns the cached encoding for model, calling
+// tiktoken.GetEncoding only on the first request for that model.
+func tiktokenEncodingFor(model string) (*tiktoken.Tiktoken, error) {
+	if enc, ok := tiktokenEncodings.Load(model); ok {
+		return enc.(*tiktoken.Tiktoken), nil
+	}
+	enc, err := tiktoken.GetEncoding(model)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := tiktokenEncodings.LoadOrStore(model, enc)
+	return actual.(*tiktoken.Tiktoken), nil
+}
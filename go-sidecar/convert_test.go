@@ -165,21 +165,21 @@ func TestStripNonContent_RemovesForm(t *testing.T) {
 
 func TestHtmlToMarkdown_FrontMatter(t *testing.T) {
 	html := loadFixture(t)
-	md, _ := htmlToMarkdown(html, nil)
+	md, _ := htmlToMarkdown(html, nil, nil, nil, "", "", ImageOptions{})
 	if !strings.HasPrefix(md, "---") {
 		t.Error("expected front matter at start")
 	}
-	if !strings.Contains(md, `title: "Testseite`) {
+	if !strings.Contains(md, `title: Testseite`) {
 		t.Error("expected title in front matter")
 	}
-	if !strings.Contains(md, `description: "Eine Testseite`) {
+	if !strings.Contains(md, `description: Eine Testseite`) {
 		t.Error("expected description in front matter")
 	}
 }
 
 func TestHtmlToMarkdown_ContainsHeading(t *testing.T) {
 	html := loadFixture(t)
-	md, _ := htmlToMarkdown(html, nil)
+	md, _ := htmlToMarkdown(html, nil, nil, nil, "", "", ImageOptions{})
 	if !strings.Contains(md, "# Willkommen auf der Testseite") {
 		t.Errorf("expected heading, got:\n%s", md)
 	}
@@ -187,7 +187,7 @@ func TestHtmlToMarkdown_ContainsHeading(t *testing.T) {
 
 func TestHtmlToMarkdown_ContainsLink(t *testing.T) {
 	html := loadFixture(t)
-	md, _ := htmlToMarkdown(html, nil)
+	md, _ := htmlToMarkdown(html, nil, nil, nil, "", "", ImageOptions{})
 	if !strings.Contains(md, "[einem Link](https://example.com)") {
 		t.Errorf("expected link, got:\n%s", md)
 	}
@@ -195,7 +195,7 @@ func TestHtmlToMarkdown_ContainsLink(t *testing.T) {
 
 func TestHtmlToMarkdown_ContainsListItems(t *testing.T) {
 	html := loadFixture(t)
-	md, _ := htmlToMarkdown(html, nil)
+	md, _ := htmlToMarkdown(html, nil, nil, nil, "", "", ImageOptions{})
 	if !strings.Contains(md, "- Markdown-Konvertierung") {
 		t.Errorf("expected list item, got:\n%s", md)
 	}
@@ -206,7 +206,7 @@ func TestHtmlToMarkdown_ContainsListItems(t *testing.T) {
 
 func TestHtmlToMarkdown_ContainsCodeBlock(t *testing.T) {
 	html := loadFixture(t)
-	md, _ := htmlToMarkdown(html, nil)
+	md, _ := htmlToMarkdown(html, nil, nil, nil, "", "", ImageOptions{})
 	if !strings.Contains(md, `print("Hello, World!")`) {
 		t.Errorf("expected code block, got:\n%s", md)
 	}
@@ -214,7 +214,7 @@ func TestHtmlToMarkdown_ContainsCodeBlock(t *testing.T) {
 
 func TestHtmlToMarkdown_StripsImages(t *testing.T) {
 	html := loadFixture(t)
-	md, _ := htmlToMarkdown(html, nil)
+	md, _ := htmlToMarkdown(html, nil, nil, nil, "", "", ImageOptions{})
 	if strings.Contains(md, "photo.jpg") {
 		t.Error("images should have been stripped")
 	}
@@ -225,7 +225,7 @@ func TestHtmlToMarkdown_StripsImages(t *testing.T) {
 
 func TestHtmlToMarkdown_StripsNavAndFooter(t *testing.T) {
 	html := loadFixture(t)
-	md, _ := htmlToMarkdown(html, nil)
+	md, _ := htmlToMarkdown(html, nil, nil, nil, "", "", ImageOptions{})
 	if strings.Contains(md, "Home") {
 		t.Error("nav links should have been stripped")
 	}
@@ -239,7 +239,7 @@ func TestHtmlToMarkdown_StripsNavAndFooter(t *testing.T) {
 
 func TestHtmlToMarkdown_StripsCookieBanner(t *testing.T) {
 	html := loadFixture(t)
-	md, _ := htmlToMarkdown(html, nil)
+	md, _ := htmlToMarkdown(html, nil, nil, nil, "", "", ImageOptions{})
 	if strings.Contains(md, "Cookies") {
 		t.Error("cookie banner should have been stripped")
 	}
@@ -247,7 +247,7 @@ func TestHtmlToMarkdown_StripsCookieBanner(t *testing.T) {
 
 func TestHtmlToMarkdown_StripsSidebar(t *testing.T) {
 	html := loadFixture(t)
-	md, _ := htmlToMarkdown(html, nil)
+	md, _ := htmlToMarkdown(html, nil, nil, nil, "", "", ImageOptions{})
 	if strings.Contains(md, "Sidebar-Inhalt") {
 		t.Error("sidebar should have been stripped")
 	}
@@ -255,14 +255,14 @@ func TestHtmlToMarkdown_StripsSidebar(t *testing.T) {
 
 func TestHtmlToMarkdown_NoExcessiveBlankLines(t *testing.T) {
 	html := loadFixture(t)
-	md, _ := htmlToMarkdown(html, nil)
+	md, _ := htmlToMarkdown(html, nil, nil, nil, "", "", ImageOptions{})
 	if strings.Contains(md, "\n\n\n\n") {
 		t.Error("should not have 4+ consecutive blank lines")
 	}
 }
 
 func TestHtmlToMarkdown_Minimal(t *testing.T) {
-	md, meta := htmlToMarkdown("<html><body><p>Hello World</p></body></html>", nil)
+	md, meta := htmlToMarkdown("<html><body><p>Hello World</p></body></html>", nil, nil, nil, "", "", ImageOptions{})
 	if !strings.Contains(md, "Hello World") {
 		t.Error("expected 'Hello World' in output")
 	}
@@ -285,7 +285,7 @@ func TestHtmlToMarkdown_ContentDivFallback(t *testing.T) {
 		</div>
 	</body>
 	</html>`
-	md, meta := htmlToMarkdown(html, nil)
+	md, meta := htmlToMarkdown(html, nil, nil, nil, "", "", ImageOptions{})
 	if !strings.Contains(md, "Fallback Content") {
 		t.Errorf("expected fallback content, got:\n%s", md)
 	}
@@ -296,7 +296,7 @@ func TestHtmlToMarkdown_ContentDivFallback(t *testing.T) {
 
 func TestHtmlToMarkdown_BlockquotePreserved(t *testing.T) {
 	html := loadFixture(t)
-	md, _ := htmlToMarkdown(html, nil)
+	md, _ := htmlToMarkdown(html, nil, nil, nil, "", "", ImageOptions{})
 	if !strings.Contains(md, "Ein Zitat zur Demonstration") {
 		t.Error("blockquote content should be preserved")
 	}
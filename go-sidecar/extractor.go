@@ -0,0 +1,250 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// outerHTML renders node (and its subtree) back to an HTML string.
+func outerHTML(node *html.Node) string {
+	var buf strings.Builder
+	if err := html.Render(&buf, node); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+// ContentExtractor isolates the main content root of a parsed HTML
+// document, replacing the one-size-fits-all main/article/#content
+// fallback chain for sites that don't fit the TYPO3 mold.
+type ContentExtractor interface {
+	Extract(doc *goquery.Document) *goquery.Selection
+}
+
+// extractorForName resolves the EXTRACTOR env var to a ContentExtractor,
+// defaulting to the original selector-chain heuristic for an unknown or
+// empty value.
+func extractorForName(name string) ContentExtractor {
+	switch strings.ToLower(name) {
+	case "readability":
+		return readabilityExtractor{}
+	case "trafilatura-like":
+		return trafilaturaLikeExtractor{}
+	case "schema-org":
+		return schemaOrgExtractor{}
+	default:
+		return selectorsExtractor{}
+	}
+}
+
+// selectorsExtractor is the original main → article → #content →
+// .content → body fallback chain.
+type selectorsExtractor struct{}
+
+func (selectorsExtractor) Extract(doc *goquery.Document) *goquery.Selection {
+	return findContentRoot(doc)
+}
+
+// schemaOrgExtractor prefers an element marked up with schema.org Article
+// semantics, which many non-TYPO3 CMSes emit even when they skip <main>.
+type schemaOrgExtractor struct{}
+
+func (schemaOrgExtractor) Extract(doc *goquery.Document) *goquery.Selection {
+	if sel := doc.Find(`[itemprop="articleBody"]`).First(); sel.Length() > 0 {
+		return sel
+	}
+
+	var articleBody *goquery.Selection
+	doc.Find("article").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		if itemtype, ok := s.Attr("itemtype"); ok && strings.Contains(itemtype, "schema.org/Article") {
+			articleBody = s
+			return false
+		}
+		return true
+	})
+	if articleBody != nil {
+		return articleBody
+	}
+
+	return selectorsExtractor{}.Extract(doc)
+}
+
+// trafilaturaLikeExtractor approximates trafilatura's density heuristic:
+// among block-level candidates, pick the one with the highest ratio of
+// text length to descendant tag count.
+type trafilaturaLikeExtractor struct{}
+
+func (trafilaturaLikeExtractor) Extract(doc *goquery.Document) *goquery.Selection {
+	var best *goquery.Selection
+	bestScore := 0.0
+
+	doc.Find("div, section, article, main").Each(func(_ int, s *goquery.Selection) {
+		text := strings.TrimSpace(s.Text())
+		if len(text) < 140 {
+			return
+		}
+		tagCount := s.Find("*").Length()
+		if tagCount == 0 {
+			tagCount = 1
+		}
+		score := float64(len(text)) / float64(tagCount)
+		if score > bestScore {
+			bestScore = score
+			best = s
+		}
+	})
+
+	if best != nil {
+		return best
+	}
+	return selectorsExtractor{}.Extract(doc)
+}
+
+// readabilityTagScore is the initial per-tag score bump, ported from
+// Mozilla Readability / arc90's scoring table.
+var readabilityTagScore = map[string]float64{
+	"div":        5,
+	"article":    8,
+	"pre":        3,
+	"td":         3,
+	"blockquote": 3,
+	"address":    -3,
+	"ol":         -3,
+	"ul":         -3,
+	"dl":         -3,
+	"dd":         -3,
+	"dt":         -3,
+	"li":         -3,
+	"form":       -3,
+	"h1":         -5,
+	"h2":         -5,
+	"h3":         -5,
+	"h4":         -5,
+	"h5":         -5,
+	"h6":         -5,
+	"th":         -5,
+}
+
+// positiveClassIDHints and negativeClassIDHints are substrings of an
+// element's class/id attribute that nudge its Readability score up or
+// down, ported from Mozilla Readability's REGEXPS.positive/negative.
+var (
+	positiveClassIDHints = []string{"article", "content", "post", "entry", "body", "main"}
+	negativeClassIDHints = []string{"comment", "share", "related", "sidebar", "promo", "foot", "nav"}
+)
+
+// classIDScore scores a node's own class and id attributes: +25 for each
+// positive keyword match, -25 for each negative keyword match.
+func classIDScore(node *html.Node) float64 {
+	var attrs string
+	for _, a := range node.Attr {
+		if a.Key == "class" || a.Key == "id" {
+			attrs += " " + strings.ToLower(a.Val)
+		}
+	}
+	if attrs == "" {
+		return 0
+	}
+
+	var score float64
+	for _, hint := range positiveClassIDHints {
+		if strings.Contains(attrs, hint) {
+			score += 25
+		}
+	}
+	for _, hint := range negativeClassIDHints {
+		if strings.Contains(attrs, hint) {
+			score -= 25
+		}
+	}
+	return score
+}
+
+// readabilityExtractor is a Go port of Mozilla Readability / arc90's
+// content-scoring algorithm.
+type readabilityExtractor struct{}
+
+func (readabilityExtractor) Extract(doc *goquery.Document) *goquery.Selection {
+	scores := make(map[*html.Node]float64)
+
+	doc.Find("p, div, article, section, pre, td, blockquote, address, ol, ul, dl, li, form, h1, h2, h3, h4, h5, h6, th").Each(func(_ int, s *goquery.Selection) {
+		node := s.Get(0)
+		text := strings.TrimSpace(s.Text())
+		if text == "" {
+			return
+		}
+
+		score := readabilityTagScore[node.Data]
+		score += float64(strings.Count(text, ","))
+		score += min(float64(len(text))/100, 3)
+		score += classIDScore(node)
+
+		scores[node] += score
+		if parent := node.Parent; parent != nil {
+			scores[parent] += score
+			if grandparent := parent.Parent; grandparent != nil {
+				scores[grandparent] += score * 0.5
+			}
+		}
+	})
+
+	var best *html.Node
+	bestScore := 0.0
+	for node, rawScore := range scores {
+		if node.Data == "html" || node.Data == "body" || node.Data == "head" {
+			continue
+		}
+		sel := goquery.NewDocumentFromNode(node).Selection
+		adjusted := rawScore * (1 - linkDensity(sel))
+		if adjusted > bestScore {
+			bestScore = adjusted
+			best = node
+		}
+	}
+
+	if best == nil {
+		return selectorsExtractor{}.Extract(doc)
+	}
+	return appendQualifyingSiblings(doc, best, scores, bestScore)
+}
+
+// appendQualifyingSiblings recovers multi-column layouts: starting from
+// the winning node, any sibling whose own (link-density-adjusted) score
+// clears a fraction of the winner's score is folded in alongside it.
+func appendQualifyingSiblings(doc *goquery.Document, best *html.Node, scores map[*html.Node]float64, bestScore float64) *goquery.Selection {
+	mergedHTML := outerHTML(best)
+
+	threshold := bestScore * 0.2
+	if parent := best.Parent; parent != nil {
+		for sibling := parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+			if sibling == best || sibling.Type != html.ElementNode {
+				continue
+			}
+			sel := goquery.NewDocumentFromNode(sibling).Selection
+			siblingScore := scores[sibling] * (1 - linkDensity(sel))
+			if siblingScore > threshold {
+				mergedHTML += outerHTML(sibling)
+			}
+		}
+	}
+
+	merged, err := goquery.NewDocumentFromReader(strings.NewReader("<div>" + mergedHTML + "</div>"))
+	if err != nil {
+		return goquery.NewDocumentFromNode(best).Selection
+	}
+	return merged.Find("div").First()
+}
+
+// linkDensity is the fraction of a selection's text that sits inside <a>
+// tags; high link density (nav blocks, related-posts widgets) should
+// pull a candidate's score down.
+func linkDensity(sel *goquery.Selection) float64 {
+	text := sel.Text()
+	if len(text) == 0 {
+		return 0
+	}
+	linkText := sel.Find("a").Text()
+	return float64(len(linkText)) / float64(len(text))
+}
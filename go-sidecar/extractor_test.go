@@ -0,0 +1,138 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestExtractorForName_Defaults(t *testing.T) {
+	if _, ok := extractorForName("").(selectorsExtractor); !ok {
+		t.Error("expected empty name to default to selectorsExtractor")
+	}
+	if _, ok := extractorForName("bogus").(selectorsExtractor); !ok {
+		t.Error("expected unknown name to default to selectorsExtractor")
+	}
+}
+
+func TestExtractorForName_Resolves(t *testing.T) {
+	if _, ok := extractorForName("readability").(readabilityExtractor); !ok {
+		t.Error("expected 'readability' to resolve to readabilityExtractor")
+	}
+	if _, ok := extractorForName("schema-org").(schemaOrgExtractor); !ok {
+		t.Error("expected 'schema-org' to resolve to schemaOrgExtractor")
+	}
+	if _, ok := extractorForName("trafilatura-like").(trafilaturaLikeExtractor); !ok {
+		t.Error("expected 'trafilatura-like' to resolve to trafilaturaLikeExtractor")
+	}
+}
+
+func TestSchemaOrgExtractor_PrefersArticleBody(t *testing.T) {
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(
+		`<html><body><div itemprop="articleBody"><p>Real content here.</p></div><nav>Nope</nav></body></html>`,
+	))
+	sel := schemaOrgExtractor{}.Extract(doc)
+	if !strings.Contains(sel.Text(), "Real content here.") {
+		t.Errorf("expected articleBody content, got %q", sel.Text())
+	}
+}
+
+func TestSchemaOrgExtractor_FallsBackWithoutMarkup(t *testing.T) {
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(
+		`<html><body><main><p>Main content.</p></main></body></html>`,
+	))
+	sel := schemaOrgExtractor{}.Extract(doc)
+	if !strings.Contains(sel.Text(), "Main content.") {
+		t.Errorf("expected fallback to <main>, got %q", sel.Text())
+	}
+}
+
+func TestReadabilityExtractor_PicksDenseTextOverNav(t *testing.T) {
+	html := `<html><body>
+		<nav><a href="/1">One</a><a href="/2">Two</a><a href="/3">Three</a></nav>
+		<div>
+			<p>This is the first paragraph of a real article, with enough words to score well, and a comma or two.</p>
+			<p>Here is a second paragraph, continuing the article with more substantial text, and another comma.</p>
+		</div>
+	</body></html>`
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(html))
+	sel := readabilityExtractor{}.Extract(doc)
+	if !strings.Contains(sel.Text(), "first paragraph of a real article") {
+		t.Errorf("expected readability to pick the article div, got %q", sel.Text())
+	}
+	if strings.Contains(sel.Text(), "One") && strings.Contains(sel.Text(), "Two") {
+		t.Error("expected readability to not select the nav block")
+	}
+}
+
+func TestReadabilityExtractor_FallsBackWhenNothingScores(t *testing.T) {
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(`<html><body><main>just text</main></body></html>`))
+	sel := readabilityExtractor{}.Extract(doc)
+	if !strings.Contains(sel.Text(), "just text") {
+		t.Errorf("expected fallback to heuristic root, got %q", sel.Text())
+	}
+}
+
+func TestReadabilityExtractor_ClassHintsBreakTies(t *testing.T) {
+	html := `<html><body>
+		<div class="sidebar-promo"><p>This sidebar div has just as many words as the other one does, really.</p></div>
+		<div class="article-content"><p>This article div has just as many words as the other one does, really.</p></div>
+	</body></html>`
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(html))
+	sel := readabilityExtractor{}.Extract(doc)
+	if strings.Contains(sel.Text(), "sidebar div") {
+		t.Errorf("expected negative class hint to suppress the sidebar div, got %q", sel.Text())
+	}
+	if !strings.Contains(sel.Text(), "article div") {
+		t.Errorf("expected positive class hint to favor the article div, got %q", sel.Text())
+	}
+}
+
+func TestReadabilityExtractor_AppendsQualifyingSiblings(t *testing.T) {
+	html := `<html><body>
+		<div class="article-content">
+			<p>This is the first paragraph of a real article, with enough words to score well, and a comma or two.</p>
+		</div>
+		<div class="article-content">
+			<p>This is a second column continuing the same article, with more substantial text, and another comma.</p>
+		</div>
+		<div class="sidebar"><p>unrelated</p></div>
+	</body></html>`
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(html))
+	sel := readabilityExtractor{}.Extract(doc)
+	if !strings.Contains(sel.Text(), "first paragraph") || !strings.Contains(sel.Text(), "second column") {
+		t.Errorf("expected both article columns to be merged, got %q", sel.Text())
+	}
+	if strings.Contains(sel.Text(), "unrelated") {
+		t.Errorf("expected the low-scoring sidebar sibling to be excluded, got %q", sel.Text())
+	}
+}
+
+func TestTrafilaturaLikeExtractor_PicksDensestBlock(t *testing.T) {
+	html := `<html><body>
+		<div class="sidebar"><ul><li>a</li><li>b</li></ul></div>
+		<article>` + strings.Repeat("Lots of real article text. ", 20) + `</article>
+	</body></html>`
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(html))
+	sel := trafilaturaLikeExtractor{}.Extract(doc)
+	if !strings.Contains(sel.Text(), "Lots of real article text.") {
+		t.Errorf("expected densest block to be the article, got %q", sel.Text())
+	}
+}
+
+func TestLinkDensity_AllLinkText(t *testing.T) {
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(`<div><a href="/x">all link text</a></div>`))
+	sel := doc.Find("div")
+	if density := linkDensity(sel); density != 1 {
+		t.Errorf("expected link density 1.0, got %v", density)
+	}
+}
+
+func TestLinkDensity_NoLinks(t *testing.T) {
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(`<div>no links here</div>`))
+	sel := doc.Find("div")
+	if density := linkDensity(sel); density != 0 {
+		t.Errorf("expected link density 0, got %v", density)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// frontMatterMeta mirrors Metadata but carries yaml/toml struct tags so
+// the proper encoders can serialize it directly, instead of hand-rolling
+// delimiter-specific escaping.
+type frontMatterMeta struct {
+	Title       string `yaml:"title,omitempty" toml:"title,omitempty" json:"title,omitempty"`
+	Description string `yaml:"description,omitempty" toml:"description,omitempty" json:"description,omitempty"`
+	Author      string `yaml:"author,omitempty" toml:"author,omitempty" json:"author,omitempty"`
+	Keywords    string `yaml:"keywords,omitempty" toml:"keywords,omitempty" json:"keywords,omitempty"`
+	Image       string `yaml:"image,omitempty" toml:"image,omitempty" json:"image,omitempty"`
+}
+
+func toFrontMatterMeta(meta Metadata) frontMatterMeta {
+	return frontMatterMeta{
+		Title:       meta.Title,
+		Description: meta.Description,
+		Author:      meta.Author,
+		Keywords:    meta.Keywords,
+		Image:       meta.Image,
+	}
+}
+
+// buildFrontMatterAs renders meta as front matter in the given format:
+// "yaml" (default), "toml", "json", "org", or "none" to omit front matter
+// entirely. An empty Metadata always produces no output.
+func buildFrontMatterAs(meta Metadata, format string) string {
+	if meta == (Metadata{}) {
+		return ""
+	}
+
+	switch format {
+	case "toml":
+		return buildTOMLFrontMatter(meta)
+	case "json":
+		return buildJSONFrontMatter(meta)
+	case "org":
+		return buildOrgFrontMatter(meta)
+	case "none":
+		return ""
+	default:
+		return buildYAMLFrontMatter(meta)
+	}
+}
+
+func buildYAMLFrontMatter(meta Metadata) string {
+	out, err := yaml.Marshal(toFrontMatterMeta(meta))
+	if err != nil {
+		return ""
+	}
+	return "---\n" + strings.TrimRight(string(out), "\n") + "\n---"
+}
+
+func buildTOMLFrontMatter(meta Metadata) string {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(toFrontMatterMeta(meta)); err != nil {
+		return ""
+	}
+	return "+++\n" + strings.TrimRight(buf.String(), "\n") + "\n+++"
+}
+
+func buildJSONFrontMatter(meta Metadata) string {
+	out, err := json.MarshalIndent(toFrontMatterMeta(meta), "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(out)
+}
+
+// buildOrgFrontMatter renders Emacs org-mode style "#+KEY: value" lines.
+func buildOrgFrontMatter(meta Metadata) string {
+	var lines []string
+	if meta.Title != "" {
+		lines = append(lines, fmt.Sprintf("#+TITLE: %s", meta.Title))
+	}
+	if meta.Description != "" {
+		lines = append(lines, fmt.Sprintf("#+DESCRIPTION: %s", meta.Description))
+	}
+	if meta.Author != "" {
+		lines = append(lines, fmt.Sprintf("#+AUTHOR: %s", meta.Author))
+	}
+	if meta.Keywords != "" {
+		lines = append(lines, fmt.Sprintf("#+KEYWORDS: %s", meta.Keywords))
+	}
+	if meta.Image != "" {
+		lines = append(lines, fmt.Sprintf("#+IMAGE: %s", meta.Image))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func sampleMeta() Metadata {
+	return Metadata{
+		Title:       `A "quoted" title: with colon`,
+		Description: "Line one\nLine two",
+		Author:      "Jane Doe",
+	}
+}
+
+func TestBuildFrontMatterAs_YAMLRoundTripsSpecialChars(t *testing.T) {
+	fm := buildFrontMatterAs(sampleMeta(), "yaml")
+	if !strings.HasPrefix(fm, "---\n") || !strings.HasSuffix(fm, "\n---") {
+		t.Errorf("expected --- delimiters, got %q", fm)
+	}
+	if !strings.Contains(fm, "Jane Doe") {
+		t.Errorf("expected author in yaml front matter, got %q", fm)
+	}
+}
+
+func TestBuildFrontMatterAs_TOML(t *testing.T) {
+	fm := buildFrontMatterAs(sampleMeta(), "toml")
+	if !strings.HasPrefix(fm, "+++\n") || !strings.HasSuffix(fm, "\n+++") {
+		t.Errorf("expected +++ delimiters, got %q", fm)
+	}
+	if !strings.Contains(fm, "Jane Doe") {
+		t.Errorf("expected author in toml front matter, got %q", fm)
+	}
+}
+
+func TestBuildFrontMatterAs_JSON(t *testing.T) {
+	fm := buildFrontMatterAs(sampleMeta(), "json")
+	if !strings.HasPrefix(fm, "{") || !strings.HasSuffix(fm, "}") {
+		t.Errorf("expected json object, got %q", fm)
+	}
+	if !strings.Contains(fm, `"author": "Jane Doe"`) {
+		t.Errorf("expected author field in json front matter, got %q", fm)
+	}
+}
+
+func TestBuildFrontMatterAs_Org(t *testing.T) {
+	fm := buildFrontMatterAs(sampleMeta(), "org")
+	if !strings.Contains(fm, "#+AUTHOR: Jane Doe") {
+		t.Errorf("expected #+AUTHOR: line, got %q", fm)
+	}
+}
+
+func TestBuildFrontMatterAs_None(t *testing.T) {
+	if fm := buildFrontMatterAs(sampleMeta(), "none"); fm != "" {
+		t.Errorf("expected empty output for format=none, got %q", fm)
+	}
+}
+
+func TestBuildFrontMatterAs_EmptyMetadataProducesNothing(t *testing.T) {
+	if fm := buildFrontMatterAs(Metadata{}, "yaml"); fm != "" {
+		t.Errorf("expected empty output for empty metadata, got %q", fm)
+	}
+}
+
+func TestBuildFrontMatterAs_DefaultsToYAML(t *testing.T) {
+	if fm := buildFrontMatterAs(sampleMeta(), ""); !strings.HasPrefix(fm, "---\n") {
+		t.Errorf("expected an empty format string to default to yaml, got %q", fm)
+	}
+}
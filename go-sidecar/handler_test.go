@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestHealthz(t *testing.T) {
@@ -48,7 +49,7 @@ func TestConvert_SuccessfulConversion(t *testing.T) {
 	}
 	client := origin.Client()
 
-	handler := convertHandler(cfg, client)
+	handler := convertHandler(cfg, client, nil, newRequestMetrics(), newIPRateLimiter(1000, 1000))
 	req := httptest.NewRequest(http.MethodGet, "/test-page", nil)
 	req.RemoteAddr = "127.0.0.1:12345"
 	w := httptest.NewRecorder()
@@ -92,7 +93,7 @@ func TestConvert_NonHTMLPassthrough(t *testing.T) {
 	}
 	client := origin.Client()
 
-	handler := convertHandler(cfg, client)
+	handler := convertHandler(cfg, client, nil, newRequestMetrics(), newIPRateLimiter(1000, 1000))
 	req := httptest.NewRequest(http.MethodGet, "/api/data", nil)
 	req.RemoteAddr = "127.0.0.1:12345"
 	w := httptest.NewRecorder()
@@ -121,7 +122,7 @@ func TestConvert_OriginErrorForwarded(t *testing.T) {
 	}
 	client := origin.Client()
 
-	handler := convertHandler(cfg, client)
+	handler := convertHandler(cfg, client, nil, newRequestMetrics(), newIPRateLimiter(1000, 1000))
 	req := httptest.NewRequest(http.MethodGet, "/not-found", nil)
 	req.RemoteAddr = "127.0.0.1:12345"
 	w := httptest.NewRecorder()
@@ -134,6 +135,273 @@ func TestConvert_OriginErrorForwarded(t *testing.T) {
 	}
 }
 
+func TestConvert_JSONFormatViaAcceptHeader(t *testing.T) {
+	sampleHTML := loadFixture(t)
+
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, sampleHTML)
+	}))
+	defer origin.Close()
+
+	cfg := Config{
+		OriginBaseURL: origin.URL,
+		OriginTimeout: 10_000_000_000,
+		ContentSignal: "ai-train=yes, search=yes, ai-input=yes",
+		TokenModel:    "cl100k_base",
+	}
+	client := origin.Client()
+
+	handler := convertHandler(cfg, client, nil, newRequestMetrics(), newIPRateLimiter(1000, 1000))
+	req := httptest.NewRequest(http.MethodGet, "/test-page", nil)
+	req.Header.Set("Accept", "application/json")
+	req.RemoteAddr = "127.0.0.1:12345"
+	w := httptest.NewRecorder()
+
+	handler(w, req)
+
+	resp := w.Result()
+	if ct := resp.Header.Get("Content-Type"); !strings.Contains(ct, "application/json") {
+		t.Errorf("expected application/json content-type, got %q", ct)
+	}
+
+	var env convertEnvelope
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		t.Fatalf("failed to decode envelope: %v", err)
+	}
+	if env.Metadata.Title != "Testseite – TYPO3 Demo" {
+		t.Errorf("expected metadata.title in envelope, got %q", env.Metadata.Title)
+	}
+	if !strings.Contains(env.Markdown, "# Willkommen") {
+		t.Error("expected markdown field in envelope")
+	}
+}
+
+func TestConvert_FormatQueryOverridesAcceptHeader(t *testing.T) {
+	sampleHTML := loadFixture(t)
+
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, sampleHTML)
+	}))
+	defer origin.Close()
+
+	cfg := Config{
+		OriginBaseURL: origin.URL,
+		OriginTimeout: 10_000_000_000,
+		TokenModel:    "cl100k_base",
+	}
+	client := origin.Client()
+
+	handler := convertHandler(cfg, client, nil, newRequestMetrics(), newIPRateLimiter(1000, 1000))
+	req := httptest.NewRequest(http.MethodGet, "/test-page?format=html", nil)
+	req.Header.Set("Accept", "application/json")
+	req.RemoteAddr = "127.0.0.1:12345"
+	w := httptest.NewRecorder()
+
+	handler(w, req)
+
+	resp := w.Result()
+	if ct := resp.Header.Get("Content-Type"); !strings.Contains(ct, "text/html") {
+		t.Errorf("expected text/html content-type, got %q", ct)
+	}
+}
+
+func TestConvert_CacheHitServesWithoutRefetching(t *testing.T) {
+	sampleHTML := loadFixture(t)
+	var originHits int
+
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		originHits++
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, sampleHTML)
+	}))
+	defer origin.Close()
+
+	cfg := Config{
+		OriginBaseURL: origin.URL,
+		OriginTimeout: 10_000_000_000,
+		TokenModel:    "cl100k_base",
+		CacheEnabled:  true,
+		CacheTTL:      time.Minute,
+		CacheCapacity: 10,
+	}
+	cc, err := newConversionCache(cfg)
+	if err != nil {
+		t.Fatalf("newConversionCache: %v", err)
+	}
+	client := origin.Client()
+	handler := convertHandler(cfg, client, cc, newRequestMetrics(), newIPRateLimiter(1000, 1000))
+
+	req1 := httptest.NewRequest(http.MethodGet, "/test-page", nil)
+	req1.RemoteAddr = "127.0.0.1:12345"
+	w1 := httptest.NewRecorder()
+	handler(w1, req1)
+
+	req2 := httptest.NewRequest(http.MethodGet, "/test-page", nil)
+	req2.RemoteAddr = "127.0.0.1:12345"
+	w2 := httptest.NewRecorder()
+	handler(w2, req2)
+
+	if originHits != 1 {
+		t.Errorf("expected origin to be hit once, got %d", originHits)
+	}
+	if w2.Result().Header.Get("ETag") == "" {
+		t.Error("expected ETag header on cached response")
+	}
+}
+
+func TestConvert_CacheConditionalRequestReturns304(t *testing.T) {
+	sampleHTML := loadFixture(t)
+
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, sampleHTML)
+	}))
+	defer origin.Close()
+
+	cfg := Config{
+		OriginBaseURL: origin.URL,
+		OriginTimeout: 10_000_000_000,
+		TokenModel:    "cl100k_base",
+		CacheEnabled:  true,
+		CacheTTL:      time.Minute,
+		CacheCapacity: 10,
+	}
+	cc, err := newConversionCache(cfg)
+	if err != nil {
+		t.Fatalf("newConversionCache: %v", err)
+	}
+	client := origin.Client()
+	handler := convertHandler(cfg, client, cc, newRequestMetrics(), newIPRateLimiter(1000, 1000))
+
+	req1 := httptest.NewRequest(http.MethodGet, "/test-page", nil)
+	req1.RemoteAddr = "127.0.0.1:12345"
+	w1 := httptest.NewRecorder()
+	handler(w1, req1)
+	etag := w1.Result().Header.Get("ETag")
+
+	req2 := httptest.NewRequest(http.MethodGet, "/test-page", nil)
+	req2.Header.Set("If-None-Match", etag)
+	req2.RemoteAddr = "127.0.0.1:12345"
+	w2 := httptest.NewRecorder()
+	handler(w2, req2)
+
+	if w2.Result().StatusCode != http.StatusNotModified {
+		t.Errorf("expected 304, got %d", w2.Result().StatusCode)
+	}
+}
+
+func TestConvert_SetsBotClassHeader(t *testing.T) {
+	sampleHTML := loadFixture(t)
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, sampleHTML)
+	}))
+	defer origin.Close()
+
+	cfg := Config{
+		OriginBaseURL: origin.URL,
+		OriginTimeout: 10_000_000_000,
+		TokenModel:    "cl100k_base",
+	}
+	client := origin.Client()
+	handler := convertHandler(cfg, client, nil, newRequestMetrics(), newIPRateLimiter(1000, 1000))
+
+	req := httptest.NewRequest(http.MethodGet, "/test-page", nil)
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; GPTBot/1.0)")
+	req.RemoteAddr = "127.0.0.1:12345"
+	w := httptest.NewRecorder()
+
+	handler(w, req)
+
+	if got := w.Result().Header.Get("X-Bot-Class"); got != "gptbot" {
+		t.Errorf("expected X-Bot-Class=gptbot, got %q", got)
+	}
+}
+
+func TestConvert_RateLimitsBotsNotHumans(t *testing.T) {
+	sampleHTML := loadFixture(t)
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, sampleHTML)
+	}))
+	defer origin.Close()
+
+	cfg := Config{
+		OriginBaseURL: origin.URL,
+		OriginTimeout: 10_000_000_000,
+		TokenModel:    "cl100k_base",
+	}
+	client := origin.Client()
+	metrics := newRequestMetrics()
+	limiter := newIPRateLimiter(0, 1)
+	handler := convertHandler(cfg, client, nil, metrics, limiter)
+
+	newReq := func(ua string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/test-page", nil)
+		req.Header.Set("User-Agent", ua)
+		req.RemoteAddr = "127.0.0.1:12345"
+		return req
+	}
+
+	w1 := httptest.NewRecorder()
+	handler(w1, newReq("Mozilla/5.0 (compatible; GPTBot/1.0)"))
+	if w1.Result().StatusCode != http.StatusOK {
+		t.Fatalf("expected first bot request to be allowed, got %d", w1.Result().StatusCode)
+	}
+
+	w2 := httptest.NewRecorder()
+	handler(w2, newReq("Mozilla/5.0 (compatible; GPTBot/1.0)"))
+	if w2.Result().StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected second bot request to be rate limited, got %d", w2.Result().StatusCode)
+	}
+
+	w3 := httptest.NewRecorder()
+	handler(w3, newReq("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15)"))
+	if w3.Result().StatusCode != http.StatusOK {
+		t.Errorf("expected human request to bypass the bot rate limit, got %d", w3.Result().StatusCode)
+	}
+}
+
+func TestConvert_RefreshQueryBypassesCache(t *testing.T) {
+	sampleHTML := loadFixture(t)
+	var originHits int
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		originHits++
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, sampleHTML)
+	}))
+	defer origin.Close()
+
+	cfg := Config{
+		OriginBaseURL: origin.URL,
+		OriginTimeout: 10_000_000_000,
+		TokenModel:    "cl100k_base",
+		CacheEnabled:  true,
+		CacheTTL:      time.Minute,
+		CacheCapacity: 10,
+	}
+	cc, err := newConversionCache(cfg)
+	if err != nil {
+		t.Fatalf("newConversionCache: %v", err)
+	}
+	client := origin.Client()
+	handler := convertHandler(cfg, client, cc, newRequestMetrics(), newIPRateLimiter(1000, 1000))
+
+	req1 := httptest.NewRequest(http.MethodGet, "/test-page", nil)
+	req1.RemoteAddr = "127.0.0.1:12345"
+	handler(httptest.NewRecorder(), req1)
+
+	req2 := httptest.NewRequest(http.MethodGet, "/test-page?refresh=1", nil)
+	req2.RemoteAddr = "127.0.0.1:12345"
+	handler(httptest.NewRecorder(), req2)
+
+	if originHits != 2 {
+		t.Errorf("expected refresh=1 to bypass the cache and refetch, got %d origin hits", originHits)
+	}
+}
+
 func TestConvert_OriginUnreachable(t *testing.T) {
 	cfg := Config{
 		OriginBaseURL: "http://127.0.0.1:19999",
@@ -142,7 +410,7 @@ func TestConvert_OriginUnreachable(t *testing.T) {
 	}
 	client := &http.Client{Timeout: cfg.OriginTimeout}
 
-	handler := convertHandler(cfg, client)
+	handler := convertHandler(cfg, client, nil, newRequestMetrics(), newIPRateLimiter(1000, 1000))
 	req := httptest.NewRequest(http.MethodGet, "/some-page", nil)
 	req.RemoteAddr = "127.0.0.1:12345"
 	w := httptest.NewRecorder()
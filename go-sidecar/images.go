@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ImageOptions controls how htmlToMarkdown handles <img> tags, replacing
+// the original unconditional removeImages call.
+type ImageOptions struct {
+	// Mode is one of "strip" (default), "keep", "inline", or "proxy".
+	Mode string
+	// BaseURL resolves relative image src attributes to absolute URLs. An
+	// empty value falls back to a <base href> tag in the document, then to
+	// the page's own URL.
+	BaseURL string
+	// MaxInlineBytes caps how large an image may be before "inline" mode
+	// gives up and falls back to "keep". Zero means no cap.
+	MaxInlineBytes int64
+	// CamoURLTemplate is the proxy URL template for "proxy" mode,
+	// containing a "{digest}" and a "{url}" placeholder, e.g.
+	// "https://images.example.com/{digest}/{url}". An empty value falls
+	// back to "{digest}/{url}".
+	CamoURLTemplate string
+	// CamoKey is the HMAC-SHA256 signing key for "proxy" mode.
+	CamoKey string
+	// Client fetches images for "inline" mode. A nil Client disables
+	// inlining and falls back to "keep".
+	Client *http.Client
+}
+
+// imageOptionsFromConfig builds the ImageOptions htmlToMarkdown needs from
+// the sidecar's Config and an HTTP client to use for "inline" downloads.
+func imageOptionsFromConfig(cfg Config, client *http.Client) ImageOptions {
+	return ImageOptions{
+		Mode:            cfg.ImagesMode,
+		BaseURL:         cfg.BaseURL,
+		MaxInlineBytes:  cfg.ImageMaxInlineBytes,
+		CamoURLTemplate: cfg.CamoURL,
+		CamoKey:         cfg.CamoKey,
+		Client:          client,
+	}
+}
+
+// imageModeForName normalizes an IMAGES_MODE value (there is no --images
+// command-line flag), defaulting to
+// "strip" (the sidecar's original, agent-focused behavior) for an empty or
+// unknown value.
+func imageModeForName(name string) string {
+	switch strings.ToLower(name) {
+	case "keep", "inline", "proxy":
+		return strings.ToLower(name)
+	default:
+		return "strip"
+	}
+}
+
+// processImages rewrites the <img> tags under sel according to opts.Mode,
+// resolving relative URLs against pageURL (and any <base href> found in
+// doc). It replaces the original unconditional removeImages.
+func processImages(doc *goquery.Document, sel *goquery.Selection, pageURL string, opts ImageOptions) {
+	mode := imageModeForName(opts.Mode)
+	if mode == "strip" {
+		removeImages(sel)
+		return
+	}
+
+	base := resolveBaseURL(doc, pageURL, opts.BaseURL)
+
+	sel.Find("img").Each(func(_ int, img *goquery.Selection) {
+		src, ok := img.Attr("src")
+		if !ok || strings.TrimSpace(src) == "" {
+			img.Remove()
+			return
+		}
+
+		absSrc := resolveURL(base, src)
+
+		switch mode {
+		case "inline":
+			if dataURI, ok := inlineImage(opts.Client, absSrc, opts.MaxInlineBytes); ok {
+				img.SetAttr("src", dataURI)
+			} else {
+				img.SetAttr("src", absSrc)
+			}
+		case "proxy":
+			img.SetAttr("src", camoURL(opts.CamoURLTemplate, opts.CamoKey, absSrc))
+		default: // "keep"
+			img.SetAttr("src", absSrc)
+		}
+
+		rewriteTitleAsFigcaption(img)
+	})
+}
+
+// resolveBaseURL picks the base URL image src attributes resolve against:
+// an explicit override, then a <base href> in the document, then the
+// page's own URL.
+func resolveBaseURL(doc *goquery.Document, pageURL, override string) string {
+	if override != "" {
+		return override
+	}
+	if doc != nil {
+		if href, ok := doc.Find("base[href]").First().Attr("href"); ok && strings.TrimSpace(href) != "" {
+			return resolveURL(pageURL, href)
+		}
+	}
+	return pageURL
+}
+
+// resolveURL resolves ref against base, returning ref unchanged if either
+// fails to parse or base is empty.
+func resolveURL(base, ref string) string {
+	if base == "" {
+		return ref
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return baseURL.ResolveReference(refURL).String()
+}
+
+// inlineImage downloads src and returns it as a base64 data URI, refusing
+// anything over maxBytes (0 means unbounded). The second return value is
+// false if the download, size check, or request setup failed, signaling
+// the caller to fall back to "keep".
+func inlineImage(client *http.Client, src string, maxBytes int64) (string, bool) {
+	if client == nil {
+		return "", false
+	}
+
+	u, err := url.Parse(src)
+	if err != nil {
+		return "", false
+	}
+	host := u.Hostname()
+	if host == "" {
+		return "", false
+	}
+	ips, ok := resolveAllowedIPs(host)
+	if !ok {
+		return "", false
+	}
+
+	req, err := http.NewRequest(http.MethodGet, src, nil)
+	if err != nil {
+		return "", false
+	}
+
+	resp, err := pinnedIPClient(client, u, ips[0]).Do(req)
+	if err != nil {
+		return "", false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return "", false
+	}
+
+	body := io.Reader(resp.Body)
+	if maxBytes > 0 {
+		body = io.LimitReader(resp.Body, maxBytes+1)
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", false
+	}
+	if maxBytes > 0 && int64(len(data)) > maxBytes {
+		return "", false
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	return "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data), true
+}
+
+// lookupIP resolves host to its IP addresses; a package variable so tests
+// can stub it out (e.g. for a loopback httptest server standing in for a
+// public image host).
+var lookupIP = net.LookupIP
+
+// isDisallowedInlineTarget reports whether src resolves to a loopback,
+// private, or link-local address. "inline" mode makes the sidecar itself
+// fetch whatever URL a converted page's <img> tags point to, so without
+// this check a malicious page could turn image inlining into an SSRF
+// probe against internal services (e.g. a cloud metadata endpoint).
+func isDisallowedInlineTarget(src string) bool {
+	u, err := url.Parse(src)
+	if err != nil {
+		return true
+	}
+
+	host := u.Hostname()
+	if host == "" {
+		return true
+	}
+
+	_, ok := resolveAllowedIPs(host)
+	return !ok
+}
+
+// resolveAllowedIPs resolves host and returns its addresses, rejecting
+// (ok=false) if resolution fails or any address is loopback, private, or
+// link-local. inlineImage pins its fetch to the first returned address
+// rather than resolving host a second time when it actually dials, so
+// this is also the set of addresses that request is allowed to connect
+// to.
+func resolveAllowedIPs(host string) ([]net.IP, bool) {
+	ips, err := lookupIP(host)
+	if err != nil || len(ips) == 0 {
+		return nil, false
+	}
+	for _, ip := range ips {
+		if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+			return nil, false
+		}
+	}
+	return ips, true
+}
+
+// pinnedDial opens the TCP connection for pinnedIPClient's transport. A
+// package variable so tests can swap it for a plain dial against addr,
+// the same way stubPublicLookupIP fakes resolution for a loopback test
+// server standing in for a public host.
+var pinnedDial = func(ctx context.Context, network, addr, pinnedAddr string) (net.Conn, error) {
+	var d net.Dialer
+	return d.DialContext(ctx, network, pinnedAddr)
+}
+
+// pinnedIPClient returns a client derived from base whose transport
+// connects directly to pinnedIP for u's host, instead of letting the
+// transport re-resolve DNS when it actually dials. Without this, an
+// attacker controlling the domain in a converted page's <img src> could
+// pass resolveAllowedIPs' check against one address (a public IP) and
+// have DNS answer with a different, internal one by the time the real
+// request connects (DNS rebinding) — pinning closes that gap by dialing
+// exactly the address that was checked.
+func pinnedIPClient(base *http.Client, u *url.URL, pinnedIP net.IP) *http.Client {
+	port := u.Port()
+	if port == "" {
+		if u.Scheme == "https" {
+			port = "443"
+		} else {
+			port = "80"
+		}
+	}
+	pinnedAddr := net.JoinHostPort(pinnedIP.String(), port)
+
+	clientCopy := *base
+	clientCopy.Transport = &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return pinnedDial(ctx, network, addr, pinnedAddr)
+		},
+	}
+	return &clientCopy
+}
+
+// camoURL rewrites imageURL through an HMAC-SHA256-signed proxy URL,
+// following the go-camo/Camo convention of a hex digest and hex-encoded
+// URL in the path. template may contain "{digest}" and "{url}"
+// placeholders; an empty template defaults to "{digest}/{url}".
+func camoURL(template, key, imageURL string) string {
+	if template == "" {
+		template = "{digest}/{url}"
+	}
+
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(imageURL))
+	digest := hex.EncodeToString(mac.Sum(nil))
+	hexURL := hex.EncodeToString([]byte(imageURL))
+
+	rewritten := strings.ReplaceAll(template, "{digest}", digest)
+	rewritten = strings.ReplaceAll(rewritten, "{url}", hexURL)
+	return rewritten
+}
+
+// rewriteTitleAsFigcaption wraps img in a <figure> with a <figcaption> when
+// it carries a title that differs from its alt text, mirroring miniflux's
+// img.title -> figcaption treatment. alt is left on the <img> itself so it
+// still lifts into the Markdown image syntax faithfully.
+func rewriteTitleAsFigcaption(img *goquery.Selection) {
+	title, hasTitle := img.Attr("title")
+	if !hasTitle || strings.TrimSpace(title) == "" {
+		return
+	}
+	if alt, _ := img.Attr("alt"); alt == title {
+		return
+	}
+
+	outer, err := goquery.OuterHtml(img)
+	if err != nil {
+		return
+	}
+	img.ReplaceWithHtml(fmt.Sprintf("<figure>%s<figcaption>%s</figcaption></figure>", outer, escapeHTML(title)))
+}
+
+// escapeHTML escapes the handful of characters unsafe to place inside a
+// figcaption built from plain text.
+func escapeHTML(s string) string {
+	s = strings.ReplaceAll(s, "&", "&amp;")
+	s = strings.ReplaceAll(s, "<", "&lt;")
+	s = strings.ReplaceAll(s, ">", "&gt;")
+	return s
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestImageModeForName_Defaults(t *testing.T) {
+	if imageModeForName("") != "strip" {
+		t.Error("expected empty name to default to strip")
+	}
+	if imageModeForName("bogus") != "strip" {
+		t.Error("expected unknown name to default to strip")
+	}
+}
+
+func TestImageModeForName_Resolves(t *testing.T) {
+	for _, mode := range []string{"keep", "inline", "proxy"} {
+		if imageModeForName(mode) != mode {
+			t.Errorf("expected %q to resolve to itself, got %q", mode, imageModeForName(mode))
+		}
+		if imageModeForName(strings.ToUpper(mode)) != mode {
+			t.Errorf("expected %q to be case-insensitive", mode)
+		}
+	}
+}
+
+func TestProcessImages_StripRemovesImg(t *testing.T) {
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(`<div><img src="photo.jpg" alt="A photo"></div>`))
+	processImages(doc, doc.Selection, "https://example.com/page", ImageOptions{Mode: "strip"})
+	if doc.Find("img").Length() != 0 {
+		t.Error("expected img to be removed in strip mode")
+	}
+}
+
+func TestProcessImages_KeepResolvesRelativeURL(t *testing.T) {
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(`<div><img src="photo.jpg" alt="A photo"></div>`))
+	processImages(doc, doc.Selection, "https://example.com/blog/post", ImageOptions{Mode: "keep"})
+
+	img := doc.Find("img")
+	if img.Length() != 1 {
+		t.Fatalf("expected img to survive keep mode")
+	}
+	src, _ := img.Attr("src")
+	if src != "https://example.com/blog/photo.jpg" {
+		t.Errorf("expected resolved src, got %q", src)
+	}
+	alt, _ := img.Attr("alt")
+	if alt != "A photo" {
+		t.Errorf("expected alt to be preserved, got %q", alt)
+	}
+}
+
+func TestProcessImages_KeepHonorsBaseTag(t *testing.T) {
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(
+		`<html><head><base href="https://cdn.example.com/assets/"></head><body><img src="photo.jpg"></body></html>`,
+	))
+	processImages(doc, doc.Find("body"), "https://example.com/blog/post", ImageOptions{Mode: "keep"})
+
+	src, _ := doc.Find("img").Attr("src")
+	if src != "https://cdn.example.com/assets/photo.jpg" {
+		t.Errorf("expected <base href> to win over the page URL, got %q", src)
+	}
+}
+
+func TestProcessImages_KeepHonorsBaseURLOverride(t *testing.T) {
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(`<div><img src="photo.jpg"></div>`))
+	processImages(doc, doc.Selection, "https://example.com/blog/post", ImageOptions{
+		Mode:    "keep",
+		BaseURL: "https://override.example.com/",
+	})
+
+	src, _ := doc.Find("img").Attr("src")
+	if src != "https://override.example.com/photo.jpg" {
+		t.Errorf("expected the BaseURL override to win, got %q", src)
+	}
+}
+
+func TestProcessImages_TitleDifferingFromAltBecomesFigcaption(t *testing.T) {
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(
+		`<div><img src="photo.jpg" alt="A photo" title="Taken at sunset"></div>`,
+	))
+	processImages(doc, doc.Selection, "https://example.com/", ImageOptions{Mode: "keep"})
+
+	if doc.Find("figure > figcaption").Length() != 1 {
+		t.Fatal("expected title to be rewritten into a figcaption")
+	}
+	if doc.Find("figure > figcaption").Text() != "Taken at sunset" {
+		t.Errorf("unexpected figcaption text: %q", doc.Find("figure > figcaption").Text())
+	}
+	if doc.Find("figure > img").Length() != 1 {
+		t.Error("expected the img to remain nested inside the figure")
+	}
+}
+
+func TestProcessImages_TitleMatchingAltStaysPlain(t *testing.T) {
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(
+		`<div><img src="photo.jpg" alt="A photo" title="A photo"></div>`,
+	))
+	processImages(doc, doc.Selection, "https://example.com/", ImageOptions{Mode: "keep"})
+
+	if doc.Find("figure").Length() != 0 {
+		t.Error("expected no figcaption when title matches alt")
+	}
+}
+
+// stubPublicLookupIP makes lookupIP report a public IP for every host, so
+// tests can exercise inline fetches against a loopback httptest server
+// without tripping the SSRF guard meant for real (non-loopback) targets.
+func stubPublicLookupIP(t *testing.T) {
+	t.Helper()
+	orig := lookupIP
+	lookupIP = func(host string) ([]net.IP, error) {
+		return []net.IP{net.ParseIP("93.184.216.34")}, nil
+	}
+	t.Cleanup(func() { lookupIP = orig })
+}
+
+// stubPinnedDialToOriginalAddr makes pinnedIPClient's transport dial the
+// address it was actually asked to dial (addr) instead of the pinned one,
+// so tests can point stubPublicLookupIP's fake public IP at a real
+// loopback httptest server. Production code keeps pinning; this only
+// opts individual tests out of it, the same way stubPublicLookupIP only
+// opts them out of the loopback/private-IP rejection.
+func stubPinnedDialToOriginalAddr(t *testing.T) {
+	t.Helper()
+	orig := pinnedDial
+	pinnedDial = func(ctx context.Context, network, addr, pinnedAddr string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, network, addr)
+	}
+	t.Cleanup(func() { pinnedDial = orig })
+}
+
+func TestIsDisallowedInlineTarget_BlocksLoopbackAndPrivate(t *testing.T) {
+	for _, src := range []string{
+		"http://127.0.0.1/secret",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://10.0.0.5/internal",
+		"http://localhost/",
+	} {
+		if !isDisallowedInlineTarget(src) {
+			t.Errorf("expected %q to be disallowed", src)
+		}
+	}
+}
+
+func TestIsDisallowedInlineTarget_AllowsPublicHost(t *testing.T) {
+	stubPublicLookupIP(t)
+	if isDisallowedInlineTarget("http://example.com/photo.jpg") {
+		t.Error("expected a public host to be allowed")
+	}
+}
+
+func TestProcessImages_InlineEncodesDataURI(t *testing.T) {
+	stubPublicLookupIP(t)
+	stubPinnedDialToOriginalAddr(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("fake-png-bytes"))
+	}))
+	defer srv.Close()
+
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(`<div><img src="photo.png"></div>`))
+	processImages(doc, doc.Selection, srv.URL+"/page", ImageOptions{
+		Mode:   "inline",
+		Client: srv.Client(),
+	})
+
+	src, _ := doc.Find("img").Attr("src")
+	if !strings.HasPrefix(src, "data:image/png;base64,") {
+		t.Errorf("expected a data URI, got %q", src)
+	}
+}
+
+func TestProcessImages_InlineFallsBackToKeepOverSizeCap(t *testing.T) {
+	stubPublicLookupIP(t)
+	stubPinnedDialToOriginalAddr(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("this-image-is-too-big"))
+	}))
+	defer srv.Close()
+
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(`<div><img src="photo.png"></div>`))
+	processImages(doc, doc.Selection, srv.URL+"/page", ImageOptions{
+		Mode:           "inline",
+		Client:         srv.Client(),
+		MaxInlineBytes: 4,
+	})
+
+	src, _ := doc.Find("img").Attr("src")
+	if strings.HasPrefix(src, "data:") {
+		t.Error("expected the oversized image to fall back to keep mode, not inline")
+	}
+	if src != srv.URL+"/photo.png" {
+		t.Errorf("expected resolved absolute URL, got %q", src)
+	}
+}
+
+// TestInlineImage_DialsResolveAllowedIPsResultNotAFreshLookup guards
+// against the DNS-rebinding gap the pinning defense closes: lookupIP is
+// stubbed to answer with a fake public IP (standing in for the address
+// resolveAllowedIPs validated), and pinnedDial is stubbed to record the
+// address it was asked to pin to while actually connecting to the test
+// server, so the test can assert the real fetch used exactly the
+// validated IP rather than re-resolving the host a second time.
+func TestInlineImage_DialsResolveAllowedIPsResultNotAFreshLookup(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("fake-png-bytes"))
+	}))
+	defer srv.Close()
+
+	_, srvPort, err := net.SplitHostPort(strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatalf("failed to split test server address: %v", err)
+	}
+
+	const fakePublicIP = "93.184.216.34"
+
+	lookupCalls := 0
+	origLookup := lookupIP
+	lookupIP = func(host string) ([]net.IP, error) {
+		lookupCalls++
+		return []net.IP{net.ParseIP(fakePublicIP)}, nil
+	}
+	t.Cleanup(func() { lookupIP = origLookup })
+
+	origDial := pinnedDial
+	var dialedPinnedAddr string
+	pinnedDial = func(ctx context.Context, network, addr, pinnedAddr string) (net.Conn, error) {
+		dialedPinnedAddr = pinnedAddr
+		var d net.Dialer
+		return d.DialContext(ctx, network, srv.Listener.Addr().String())
+	}
+	t.Cleanup(func() { pinnedDial = origDial })
+
+	data, ok := inlineImage(srv.Client(), "http://example.com:"+srvPort+"/photo.png", 0)
+	if !ok {
+		t.Fatal("expected inlineImage to succeed")
+	}
+	if !strings.HasPrefix(data, "data:image/png;base64,") {
+		t.Errorf("expected a data URI, got %q", data)
+	}
+	if lookupCalls != 1 {
+		t.Errorf("expected exactly one DNS lookup (no re-resolution at dial time), got %d", lookupCalls)
+	}
+	if dialedPinnedAddr != net.JoinHostPort(fakePublicIP, srvPort) {
+		t.Errorf("expected the dial to pin the resolveAllowedIPs-checked address %s, got %s", net.JoinHostPort(fakePublicIP, srvPort), dialedPinnedAddr)
+	}
+}
+
+func TestProcessImages_ProxySignsURL(t *testing.T) {
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(`<div><img src="photo.jpg"></div>`))
+	processImages(doc, doc.Selection, "https://example.com/", ImageOptions{
+		Mode:            "proxy",
+		CamoURLTemplate: "https://images.example.com/{digest}/{url}",
+		CamoKey:         "s3cr3t",
+	})
+
+	src, _ := doc.Find("img").Attr("src")
+	if !strings.HasPrefix(src, "https://images.example.com/") {
+		t.Errorf("expected the camo template to be applied, got %q", src)
+	}
+	if strings.Contains(src, "{digest}") || strings.Contains(src, "{url}") {
+		t.Errorf("expected template placeholders to be substituted, got %q", src)
+	}
+}
+
+func TestCamoURL_DeterministicForSameKey(t *testing.T) {
+	a := camoURL("{digest}/{url}", "key", "https://example.com/a.jpg")
+	b := camoURL("{digest}/{url}", "key", "https://example.com/a.jpg")
+	if a != b {
+		t.Error("expected camoURL to be deterministic for the same key and URL")
+	}
+
+	c := camoURL("{digest}/{url}", "other-key", "https://example.com/a.jpg")
+	if a == c {
+		t.Error("expected a different key to produce a different digest")
+	}
+}
@@ -0,0 +1,59 @@
+// Package cache provides a conversion cache for the markdown sidecar,
+// keyed on the origin URL plus two of the options that affect the
+// converted output (extra strip selectors, token model). It supports a bounded
+// in-memory LRU and an optional filesystem-backed Store, and a
+// stale-while-revalidate refresh helper for background updates.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Entry is a cached conversion result. Metadata is kept as a flat string
+// map rather than the sidecar's own Metadata type so this package has no
+// dependency on the main package.
+type Entry struct {
+	Markdown           string
+	Metadata           map[string]string
+	Tokens             int
+	OriginETag         string
+	OriginLastModified string
+	FetchedAt          time.Time
+	ExpiresAt          time.Time
+}
+
+// Stale reports whether the entry is past its expiry and should be
+// revalidated, even though it can still be served immediately.
+func (e Entry) Stale(now time.Time) bool {
+	return now.After(e.ExpiresAt)
+}
+
+// Store is the cache backend contract. Implementations must be safe for
+// concurrent use.
+type Store interface {
+	Get(key string) (Entry, bool)
+	Set(key string, entry Entry)
+}
+
+// Key builds a deterministic cache key from the origin URL, the extra
+// strip selectors and the token model. Other settings that change the
+// converted output (extractor, renderer, front matter format, image mode)
+// are not part of the key. Selectors are sorted so that
+// equivalent STRIP_SELECTORS configurations in any order hash the same.
+func Key(originURL string, extraSelectors []string, tokenModel string) string {
+	sorted := make([]string, len(extraSelectors))
+	copy(sorted, extraSelectors)
+	sort.Strings(sorted)
+
+	h := sha256.New()
+	h.Write([]byte(originURL))
+	h.Write([]byte{0})
+	h.Write([]byte(strings.Join(sorted, ",")))
+	h.Write([]byte{0})
+	h.Write([]byte(tokenModel))
+	return hex.EncodeToString(h.Sum(nil))
+}
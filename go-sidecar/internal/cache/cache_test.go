@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKey_DeterministicRegardlessOfSelectorOrder(t *testing.T) {
+	a := Key("https://example.com/page", []string{".foo", ".bar"}, "cl100k_base")
+	b := Key("https://example.com/page", []string{".bar", ".foo"}, "cl100k_base")
+	if a != b {
+		t.Errorf("expected selector order to not affect the key, got %q vs %q", a, b)
+	}
+}
+
+func TestKey_DiffersByURL(t *testing.T) {
+	a := Key("https://example.com/a", nil, "cl100k_base")
+	b := Key("https://example.com/b", nil, "cl100k_base")
+	if a == b {
+		t.Error("expected different URLs to produce different keys")
+	}
+}
+
+func TestKey_DiffersByTokenModel(t *testing.T) {
+	a := Key("https://example.com/page", nil, "cl100k_base")
+	b := Key("https://example.com/page", nil, "o200k_base")
+	if a == b {
+		t.Error("expected different token models to produce different keys")
+	}
+}
+
+func TestEntry_Stale(t *testing.T) {
+	now := time.Now()
+	fresh := Entry{ExpiresAt: now.Add(time.Minute)}
+	if fresh.Stale(now) {
+		t.Error("expected entry with future expiry to not be stale")
+	}
+
+	expired := Entry{ExpiresAt: now.Add(-time.Minute)}
+	if !expired.Stale(now) {
+		t.Error("expected entry with past expiry to be stale")
+	}
+}
+
+func TestLRU_SetAndGet(t *testing.T) {
+	c := NewLRU(2)
+	c.Set("a", Entry{Markdown: "A"})
+
+	entry, ok := c.Get("a")
+	if !ok {
+		t.Fatal("expected key 'a' to be present")
+	}
+	if entry.Markdown != "A" {
+		t.Errorf("expected markdown 'A', got %q", entry.Markdown)
+	}
+}
+
+func TestLRU_MissingKey(t *testing.T) {
+	c := NewLRU(2)
+	if _, ok := c.Get("missing"); ok {
+		t.Error("expected missing key to report not found")
+	}
+}
+
+func TestLRU_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewLRU(2)
+	c.Set("a", Entry{Markdown: "A"})
+	c.Set("b", Entry{Markdown: "B"})
+	c.Get("a") // touch "a" so "b" becomes the least recently used
+	c.Set("c", Entry{Markdown: "C"})
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected 'b' to have been evicted")
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Error("expected 'a' to still be cached")
+	}
+	if _, ok := c.Get("c"); !ok {
+		t.Error("expected 'c' to be cached")
+	}
+}
+
+func TestLRU_Len(t *testing.T) {
+	c := NewLRU(5)
+	c.Set("a", Entry{})
+	c.Set("b", Entry{})
+	if got := c.Len(); got != 2 {
+		t.Errorf("expected len 2, got %d", got)
+	}
+}
+
+func TestRefresher_StoresFetchResultOnSuccess(t *testing.T) {
+	store := NewLRU(10)
+	r := NewRefresher(store, time.Second)
+
+	done := make(chan struct{})
+	r.Trigger("key", func() (Entry, error) {
+		defer close(done)
+		return Entry{Markdown: "refreshed"}, nil
+	}, nil)
+
+	<-done
+	// The store write happens just before the fetch goroutine returns;
+	// give it a moment to land.
+	time.Sleep(10 * time.Millisecond)
+
+	entry, ok := store.Get("key")
+	if !ok || entry.Markdown != "refreshed" {
+		t.Errorf("expected refreshed entry to be stored, got %+v (ok=%v)", entry, ok)
+	}
+}
+
+func TestRefresher_SkipsConcurrentRefreshForSameKey(t *testing.T) {
+	store := NewLRU(10)
+	r := NewRefresher(store, time.Second)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	calls := 0
+
+	r.Trigger("key", func() (Entry, error) {
+		calls++
+		close(started)
+		<-release
+		return Entry{}, nil
+	}, nil)
+
+	<-started
+	r.Trigger("key", func() (Entry, error) {
+		calls++
+		return Entry{}, nil
+	}, nil)
+
+	close(release)
+	time.Sleep(10 * time.Millisecond)
+
+	if calls != 1 {
+		t.Errorf("expected only one fetch to run for an in-flight key, got %d", calls)
+	}
+}
@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// FileStore is a filesystem-backed Store, one JSON file per entry. It is
+// meant to sit behind an LRU as a second-level cache that survives
+// restarts; it does no in-memory bookkeeping of its own.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore returns a FileStore rooted at dir, creating it if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+func (f *FileStore) path(key string) string {
+	return filepath.Join(f.dir, key+".json")
+}
+
+// Get reads the entry for key from disk, if present.
+func (f *FileStore) Get(key string) (Entry, bool) {
+	data, err := os.ReadFile(f.path(key))
+	if err != nil {
+		return Entry{}, false
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return Entry{}, false
+	}
+	return entry, true
+}
+
+// Set writes entry for key to disk, overwriting any previous value.
+func (f *FileStore) Set(key string, entry Entry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	// Best effort: a failed cache write should never fail the request path.
+	_ = os.WriteFile(f.path(key), data, 0o644)
+}
@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// errUpdateTimeout is passed to Trigger's onTimeout callback (which the
+// caller uses to log it) when a background refresh did not complete
+// within its deadline. Borrowed from
+// gddo-server's pattern of never letting a slow origin block the request
+// path: the stale entry keeps being served until a refresh finally lands.
+var errUpdateTimeout = errors.New("cache: background refresh timed out")
+
+// Refresher runs background revalidations for a Store, making sure at
+// most one refresh is in flight per key at a time.
+type Refresher struct {
+	store   Store
+	timeout time.Duration
+
+	mu       sync.Mutex
+	inFlight map[string]bool
+}
+
+// NewRefresher returns a Refresher that gives each background update up to
+// timeout to complete before giving up (the goroutine keeps running; the
+// caller just stops waiting on it).
+func NewRefresher(store Store, timeout time.Duration) *Refresher {
+	return &Refresher{
+		store:    store,
+		timeout:  timeout,
+		inFlight: make(map[string]bool),
+	}
+}
+
+// Trigger starts a background refresh for key unless one is already
+// running, calling fetch to produce the new Entry and storing it on
+// success. onTimeout, if non-nil, is invoked with errUpdateTimeout when
+// the refresh is still running after the configured timeout; the refresh
+// itself is not canceled, so a subsequent Trigger for the same key is a
+// no-op until it finishes.
+func (r *Refresher) Trigger(key string, fetch func() (Entry, error), onTimeout func(error)) {
+	r.mu.Lock()
+	if r.inFlight[key] {
+		r.mu.Unlock()
+		return
+	}
+	r.inFlight[key] = true
+	r.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() {
+			r.mu.Lock()
+			delete(r.inFlight, key)
+			r.mu.Unlock()
+		}()
+
+		entry, err := fetch()
+		if err != nil {
+			return
+		}
+		r.store.Set(key, entry)
+	}()
+
+	go func() {
+		select {
+		case <-done:
+		case <-time.After(r.timeout):
+			if onTimeout != nil {
+				onTimeout(errUpdateTimeout)
+			}
+		}
+	}()
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -11,17 +12,35 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/oliverbe/md-for-varnish/go-sidecar/internal/cache"
 )
 
 // Config holds the sidecar configuration from environment variables.
 type Config struct {
-	OriginBaseURL   string
-	OriginTimeout   time.Duration
-	ContentSignal   string
-	TokenModel      string
-	LogLevel        slog.Level
-	ExtraSelectors  []string
-	ListenAddr      string
+	OriginBaseURL       string
+	OriginTimeout       time.Duration
+	ContentSignal       string
+	TokenModel          string
+	LogLevel            slog.Level
+	ExtraSelectors      []string
+	ListenAddr          string
+	CacheEnabled        bool
+	CacheTTL            time.Duration
+	CacheCapacity       int
+	CacheDir            string
+	MaxConcurrency      int
+	Extractor           string
+	BotRateLimit        float64
+	BotRateBurst        float64
+	Renderer            string
+	RenderExtensions    string
+	FrontMatter         string
+	ImagesMode          string
+	BaseURL             string
+	ImageMaxInlineBytes int64
+	CamoURL             string
+	CamoKey             string
 }
 
 func loadConfig() Config {
@@ -57,6 +76,49 @@ func loadConfig() Config {
 		}
 	}
 
+	cfg.CacheEnabled = getenv("CACHE_ENABLED", "false") == "true"
+	cfg.CacheDir = os.Getenv("CACHE_DIR")
+
+	cacheTTLSec, err := strconv.Atoi(getenv("CACHE_TTL", "300"))
+	if err != nil {
+		cacheTTLSec = 300
+	}
+	cfg.CacheTTL = time.Duration(cacheTTLSec) * time.Second
+
+	cfg.CacheCapacity, err = strconv.Atoi(getenv("CACHE_CAPACITY", "1000"))
+	if err != nil {
+		cfg.CacheCapacity = 1000
+	}
+
+	cfg.MaxConcurrency, err = strconv.Atoi(getenv("MAX_CONCURRENCY", "5"))
+	if err != nil {
+		cfg.MaxConcurrency = 5
+	}
+
+	cfg.Extractor = getenv("EXTRACTOR", "selectors")
+
+	cfg.BotRateLimit, err = strconv.ParseFloat(getenv("BOT_RATE_LIMIT", "2"), 64)
+	if err != nil {
+		cfg.BotRateLimit = 2
+	}
+	cfg.BotRateBurst, err = strconv.ParseFloat(getenv("BOT_RATE_BURST", "10"), 64)
+	if err != nil {
+		cfg.BotRateBurst = 10
+	}
+
+	cfg.Renderer = getenv("RENDERER", "htm2md")
+	cfg.RenderExtensions = os.Getenv("RENDERER_EXTENSIONS")
+	cfg.FrontMatter = getenv("FRONTMATTER", "yaml")
+
+	cfg.ImagesMode = getenv("IMAGES_MODE", "strip")
+	cfg.BaseURL = os.Getenv("BASE_URL")
+	cfg.ImageMaxInlineBytes, err = strconv.ParseInt(getenv("IMAGE_MAX_INLINE_BYTES", "1048576"), 10, 64)
+	if err != nil {
+		cfg.ImageMaxInlineBytes = 1048576
+	}
+	cfg.CamoURL = os.Getenv("CAMO_URL")
+	cfg.CamoKey = os.Getenv("CAMO_KEY")
+
 	return cfg
 }
 
@@ -77,6 +139,12 @@ func main() {
 		os.Exit(0)
 	}
 
+	// Built-in batch mode: crawl a list of URLs from the command line
+	// instead of serving HTTP requests.
+	if len(os.Args) > 1 && os.Args[1] == "-batch" {
+		os.Exit(runBatchCLI(os.Args[2:], loadConfig()))
+	}
+
 	cfg := loadConfig()
 
 	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
@@ -93,12 +161,24 @@ func main() {
 		},
 	}
 
+	cc, err := newConversionCache(cfg)
+	if err != nil {
+		slog.Error("Failed to initialize cache", "error", err)
+		os.Exit(1)
+	}
+
+	reqMetrics := newRequestMetrics()
+	botLimiter := newIPRateLimiter(cfg.BotRateLimit, cfg.BotRateBurst)
+
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /healthz", handleHealthz)
-	mux.HandleFunc("GET /{path...}", convertHandler(cfg, client))
+	mux.HandleFunc("GET /metrics", reqMetrics.Handler())
+	mux.HandleFunc("POST /_batch", batchHandler(cfg, client))
+	mux.HandleFunc("GET /_corpus", corpusHandler(cfg, client))
+	mux.HandleFunc("GET /{path...}", convertHandler(cfg, client, cc, reqMetrics, botLimiter))
 
 	slog.Info("Starting markdown-sidecar", "addr", cfg.ListenAddr, "origin", cfg.OriginBaseURL)
-	if err := http.ListenAndServe(cfg.ListenAddr, mux); err != nil {
+	if err := http.ListenAndServe(cfg.ListenAddr, withCompression(mux)); err != nil {
 		slog.Error("Server failed", "error", err)
 		os.Exit(1)
 	}
@@ -112,11 +192,36 @@ func handleHealthz(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func convertHandler(cfg Config, client *http.Client) http.HandlerFunc {
+func convertHandler(cfg Config, client *http.Client, cc *conversionCache, reqMetrics *requestMetrics, botLimiter *ipRateLimiter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		class := classifyRequest(r)
+		reqMetrics.Inc(class)
+		w.Header().Set("X-Bot-Class", string(class))
+
+		if class.isBot() && !botLimiter.Allow(clientIP(r)) {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+
 		// Build origin URL
 		originURL := buildOriginURL(cfg.OriginBaseURL, r.URL)
 
+		// Only humans and API clients may force a fresh conversion; bots are
+		// always served an existing cache entry, stale or not.
+		forceRefresh := !class.isBot() && r.URL.Query().Get("refresh") == "1"
+
+		var cacheKey string
+		if cc != nil {
+			cacheKey = cache.Key(originURL, cfg.ExtraSelectors, cfg.TokenModel)
+			if !forceRefresh {
+				if entry, ok := cc.store.Get(cacheKey); ok {
+					serveFromCache(w, r, cfg, cc, client, originURL, cacheKey, entry, class)
+					return
+				}
+			}
+		}
+
 		slog.Info("Converting", "url", originURL)
 		start := time.Now()
 
@@ -153,7 +258,7 @@ func convertHandler(cfg Config, client *http.Client) http.HandlerFunc {
 			return
 		}
 
-		// Non-HTML passthrough
+		// Non-HTML passthrough (never cached: there is no markdown to cache)
 		contentType := resp.Header.Get("Content-Type")
 		if !strings.Contains(contentType, "text/html") {
 			for k, vals := range resp.Header {
@@ -175,24 +280,154 @@ func convertHandler(cfg Config, client *http.Client) http.HandlerFunc {
 		}
 
 		// Convert
-		mdText, _ := htmlToMarkdown(string(body), cfg.ExtraSelectors)
+		mdText, meta := htmlToMarkdown(string(body), cfg.ExtraSelectors, extractorForName(cfg.Extractor), rendererForName(cfg.Renderer, ParseRenderOptions(cfg.RenderExtensions)), cfg.FrontMatter, originURL, imageOptionsFromConfig(cfg, client))
 		tokenCount := countTokens(mdText, cfg.TokenModel)
 		durationMs := time.Since(start).Milliseconds()
 
 		slog.Info("Converted", "url", originURL, "tokens", tokenCount, "duration_ms", durationMs)
 
-		// Set response headers
-		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
-		w.Header().Set("X-Markdown-Tokens", strconv.Itoa(tokenCount))
-		w.Header().Set("X-Conversion-Time-Ms", strconv.FormatInt(durationMs, 10))
-		w.Header().Set("Content-Signal", cfg.ContentSignal)
-		w.Header().Set("Vary", "Accept")
-		w.Header().Set("Cache-Control", "public, max-age=300")
+		if cc != nil {
+			entry := entryFromConversion(mdText, meta, tokenCount, resp.Header.Get("ETag"), resp.Header.Get("Last-Modified"), cc.ttl)
+			cc.store.Set(cacheKey, entry)
+			if writeConditionalHeaders(w, r, etagFor(mdText), entry.FetchedAt) {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+
+		writeConvertResponse(w, r, cfg, originURL, mdText, meta, tokenCount, durationMs, class)
+	}
+}
+
+// clientIP extracts the request's source IP, stripping the port from
+// RemoteAddr.
+func clientIP(r *http.Request) string {
+	host := r.RemoteAddr
+	if idx := strings.LastIndex(host, ":"); idx != -1 {
+		host = host[:idx]
+	}
+	return host
+}
+
+// serveFromCache answers a request from a cached entry: it handles
+// conditional requests (304), serves stale entries immediately while
+// kicking off a background refresh, and otherwise writes the cached
+// representation directly.
+func serveFromCache(w http.ResponseWriter, r *http.Request, cfg Config, cc *conversionCache, client *http.Client, originURL, cacheKey string, entry cache.Entry, class botClass) {
+	etag := etagFor(entry.Markdown)
+	notModified := writeConditionalHeaders(w, r, etag, entry.FetchedAt)
+
+	if entry.Stale(time.Now()) {
+		cc.refresher.Trigger(cacheKey, func() (cache.Entry, error) {
+			return refreshOrigin(context.Background(), cfg, client, originURL, entry, cc.ttl)
+		}, func(err error) {
+			slog.Warn("Background cache refresh did not finish in time", "url", originURL, "error", err)
+		})
+	}
+
+	if notModified {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	meta := metadataFromMap(entry.Metadata)
+	durationMs := time.Since(entry.FetchedAt).Milliseconds()
+	writeConvertResponse(w, r, cfg, originURL, entry.Markdown, meta, entry.Tokens, durationMs, class)
+}
+
+// refreshOrigin revalidates a cached entry against the origin with a
+// conditional GET (using the origin's own ETag/Last-Modified, not ours),
+// re-converting only if the origin reports a change.
+func refreshOrigin(ctx context.Context, cfg Config, client *http.Client, originURL string, prev cache.Entry, ttl time.Duration) (cache.Entry, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, originURL, nil)
+	if err != nil {
+		return cache.Entry{}, fmt.Errorf("build revalidation request: %w", err)
+	}
+	req.Header.Set("Accept", "text/html")
+	req.Header.Set("User-Agent", "markdown-sidecar/0.1")
+	if prev.OriginETag != "" {
+		req.Header.Set("If-None-Match", prev.OriginETag)
+	}
+	if prev.OriginLastModified != "" {
+		req.Header.Set("If-Modified-Since", prev.OriginLastModified)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return cache.Entry{}, fmt.Errorf("revalidate origin: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		refreshed := prev
+		refreshed.FetchedAt = time.Now()
+		refreshed.ExpiresAt = refreshed.FetchedAt.Add(ttl)
+		return refreshed, nil
+	}
+	if resp.StatusCode >= 400 {
+		return cache.Entry{}, fmt.Errorf("origin returned status %d during revalidation", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return cache.Entry{}, fmt.Errorf("read origin response: %w", err)
+	}
+
+	mdText, meta := htmlToMarkdown(string(body), cfg.ExtraSelectors, extractorForName(cfg.Extractor), rendererForName(cfg.Renderer, ParseRenderOptions(cfg.RenderExtensions)), cfg.FrontMatter, originURL, imageOptionsFromConfig(cfg, client))
+	tokenCount := countTokens(mdText, cfg.TokenModel)
+	return entryFromConversion(mdText, meta, tokenCount, resp.Header.Get("ETag"), resp.Header.Get("Last-Modified"), ttl), nil
+}
+
+// writeConvertResponse writes the negotiated representation of a
+// conversion (Markdown, JSON envelope, or rerendered HTML).
+func writeConvertResponse(w http.ResponseWriter, r *http.Request, cfg Config, originURL, mdText string, meta Metadata, tokenCount int, durationMs int64, class botClass) {
+	w.Header().Set("X-Markdown-Tokens", strconv.Itoa(tokenCount))
+	w.Header().Set("X-Conversion-Time-Ms", strconv.FormatInt(durationMs, 10))
+	w.Header().Set("Content-Signal", cfg.ContentSignal)
+	w.Header().Add("Vary", "Accept")
+	w.Header().Set("Cache-Control", cacheControlFor(class))
+
+	switch negotiateFormat(r) {
+	case formatJSON:
+		w.Header().Set("Content-Type", jsonMIMEType+"; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(convertEnvelope{
+			URL:           originURL,
+			Metadata:      meta,
+			Markdown:      mdText,
+			Tokens:        tokenCount,
+			DurationMs:    durationMs,
+			ContentSignal: cfg.ContentSignal,
+		})
+	case formatHTML:
+		htmlBody, err := renderMarkdownHTML(mdText)
+		if err != nil {
+			slog.Error("Failed to render html", "error", err)
+			http.Error(w, "Failed to render html", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", htmlMIMEType+"; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, htmlBody)
+	default:
+		w.Header().Set("Content-Type", markdownMIMEType+"; charset=utf-8")
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprint(w, mdText)
 	}
 }
 
+// metadataFromMap rebuilds a Metadata struct from the flat string map
+// stored in a cache.Entry.
+func metadataFromMap(m map[string]string) Metadata {
+	return Metadata{
+		Title:       m["title"],
+		Description: m["description"],
+		Author:      m["author"],
+		Keywords:    m["keywords"],
+		Image:       m["image"],
+	}
+}
+
 // buildOriginURL constructs the full origin URL from the base URL and request path.
 func buildOriginURL(baseURL string, reqURL *url.URL) string {
 	base := strings.TrimRight(baseURL, "/")
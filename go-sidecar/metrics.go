@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// requestMetrics counts requests reaching the convert handler per bot
+// class (including ones later rate limited or passed through unconverted),
+// exposed in Prometheus text exposition format at /metrics. A hand-rolled
+// counter is enough for this one counter family, so the sidecar doesn't need to pull
+// in the full client_golang dependency tree for it.
+type requestMetrics struct {
+	mu     sync.Mutex
+	counts map[botClass]int64
+}
+
+func newRequestMetrics() *requestMetrics {
+	return &requestMetrics{counts: make(map[botClass]int64)}
+}
+
+// Inc increments the counter for class.
+func (m *requestMetrics) Inc(class botClass) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counts[class]++
+}
+
+// Handler serves the counters as a Prometheus text exposition document.
+func (m *requestMetrics) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		classes := make([]botClass, 0, len(m.counts))
+		for class := range m.counts {
+			classes = append(classes, class)
+		}
+		sort.Slice(classes, func(i, j int) bool { return classes[i] < classes[j] })
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		fmt.Fprintln(w, "# HELP markdown_sidecar_requests_total Requests to the convert handler by bot classification.")
+		fmt.Fprintln(w, "# TYPE markdown_sidecar_requests_total counter")
+		for _, class := range classes {
+			fmt.Fprintf(w, "markdown_sidecar_requests_total{class=%q} %d\n", class, m.counts[class])
+		}
+		m.mu.Unlock()
+	}
+}
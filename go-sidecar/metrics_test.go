@@ -0,0 +1,37 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestMetrics_IncAndHandler(t *testing.T) {
+	m := newRequestMetrics()
+	m.Inc(botClassGPT)
+	m.Inc(botClassGPT)
+	m.Inc(botClassHuman)
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	m.Handler()(w, req)
+
+	body := w.Body.String()
+	if !strings.Contains(body, `markdown_sidecar_requests_total{class="gptbot"} 2`) {
+		t.Errorf("expected gptbot count of 2 in output, got:\n%s", body)
+	}
+	if !strings.Contains(body, `markdown_sidecar_requests_total{class="human"} 1`) {
+		t.Errorf("expected human count of 1 in output, got:\n%s", body)
+	}
+}
+
+func TestRequestMetrics_EmptyHandler(t *testing.T) {
+	m := newRequestMetrics()
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	m.Handler()(w, req)
+
+	if !strings.Contains(w.Body.String(), "# HELP") {
+		t.Errorf("expected exposition header even with no counters, got:\n%s", w.Body.String())
+	}
+}
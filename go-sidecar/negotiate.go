@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// MIME types recognized for content negotiation, named the way gddo-server
+// names its jsonMIMEType / textMIMEType / htmlMIMEType constants.
+const (
+	jsonMIMEType     = "application/json"
+	htmlMIMEType     = "text/html"
+	markdownMIMEType = "text/markdown"
+)
+
+// responseFormat is the representation of a conversion to send back.
+type responseFormat int
+
+const (
+	formatMarkdown responseFormat = iota
+	formatJSON
+	formatHTML
+)
+
+// negotiateFormat picks the response representation from an explicit
+// ?format= override first, falling back to the Accept header, and
+// defaulting to the plain Markdown representation.
+func negotiateFormat(r *http.Request) responseFormat {
+	if f := strings.ToLower(r.URL.Query().Get("format")); f != "" {
+		switch f {
+		case "json":
+			return formatJSON
+		case "html":
+			return formatHTML
+		case "markdown", "md":
+			return formatMarkdown
+		}
+	}
+
+	accept := r.Header.Get("Accept")
+	switch {
+	case strings.Contains(accept, jsonMIMEType):
+		return formatJSON
+	case strings.Contains(accept, htmlMIMEType):
+		return formatHTML
+	default:
+		return formatMarkdown
+	}
+}
+
+// convertEnvelope is the structured JSON representation of a conversion,
+// used when a client negotiates application/json instead of text/markdown.
+type convertEnvelope struct {
+	URL           string   `json:"url"`
+	Metadata      Metadata `json:"metadata"`
+	Markdown      string   `json:"markdown"`
+	Tokens        int      `json:"tokens"`
+	DurationMs    int64    `json:"duration_ms"`
+	ContentSignal string   `json:"content_signal"`
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiateFormat_DefaultsToMarkdown(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/page", nil)
+	if got := negotiateFormat(req); got != formatMarkdown {
+		t.Errorf("expected formatMarkdown, got %v", got)
+	}
+}
+
+func TestNegotiateFormat_AcceptJSON(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/page", nil)
+	req.Header.Set("Accept", "application/json")
+	if got := negotiateFormat(req); got != formatJSON {
+		t.Errorf("expected formatJSON, got %v", got)
+	}
+}
+
+func TestNegotiateFormat_AcceptHTML(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/page", nil)
+	req.Header.Set("Accept", "text/html")
+	if got := negotiateFormat(req); got != formatHTML {
+		t.Errorf("expected formatHTML, got %v", got)
+	}
+}
+
+func TestNegotiateFormat_QueryOverride(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/page?format=json", nil)
+	req.Header.Set("Accept", "text/html")
+	if got := negotiateFormat(req); got != formatJSON {
+		t.Errorf("expected query override to formatJSON, got %v", got)
+	}
+}
+
+func TestNegotiateFormat_UnknownQueryFallsBackToAccept(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/page?format=xml", nil)
+	req.Header.Set("Accept", "text/html")
+	if got := negotiateFormat(req); got != formatHTML {
+		t.Errorf("expected fallback to Accept header, got %v", got)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// maxRateLimiterBuckets bounds how many per-IP buckets ipRateLimiter keeps
+// at once. Bot classification is a User-Agent substring match an attacker
+// can vary trivially, so without a cap a client cycling through source
+// IPs could grow this map without bound on a long-running process.
+const maxRateLimiterBuckets = 10000
+
+// ipRateLimiter is a small per-IP token bucket, bounded to
+// maxRateLimiterBuckets entries (evicting the least recently used IP past
+// that), much like the conversion cache's LRU. It is only consulted for
+// bot-classified traffic; humans and API clients are never rate limited
+// here (Varnish/upstream handles that layer for them).
+type ipRateLimiter struct {
+	rate  float64 // tokens added per second
+	burst float64 // bucket capacity
+
+	mu      sync.Mutex
+	ll      *list.List
+	buckets map[string]*list.Element
+}
+
+type bucket struct {
+	ip         string
+	tokens     float64
+	lastRefill time.Time
+}
+
+// newIPRateLimiter returns a limiter allowing ratePerSecond sustained
+// requests per IP, bursting up to burst.
+func newIPRateLimiter(ratePerSecond float64, burst float64) *ipRateLimiter {
+	return &ipRateLimiter{
+		rate:    ratePerSecond,
+		burst:   burst,
+		ll:      list.New(),
+		buckets: make(map[string]*list.Element),
+	}
+}
+
+// Allow reports whether a request from ip may proceed, consuming one
+// token if so.
+func (l *ipRateLimiter) Allow(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+
+	var b *bucket
+	if el, ok := l.buckets[ip]; ok {
+		b = el.Value.(*bucket)
+		l.ll.MoveToFront(el)
+	} else {
+		b = &bucket{ip: ip, tokens: l.burst, lastRefill: now}
+		l.buckets[ip] = l.ll.PushFront(b)
+		l.evictIfOverCapacity()
+	}
+
+	elapsed := now.Sub(b.lastRefill).Seconds()
+	b.tokens = min(b.tokens+elapsed*l.rate, l.burst)
+	b.lastRefill = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// evictIfOverCapacity drops the least recently used bucket once the
+// limiter holds more than maxRateLimiterBuckets entries. Callers must
+// hold l.mu.
+func (l *ipRateLimiter) evictIfOverCapacity() {
+	if l.ll.Len() <= maxRateLimiterBuckets {
+		return
+	}
+	oldest := l.ll.Back()
+	if oldest == nil {
+		return
+	}
+	l.ll.Remove(oldest)
+	delete(l.buckets, oldest.Value.(*bucket).ip)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestIPRateLimiter_AllowsUpToBurst(t *testing.T) {
+	l := newIPRateLimiter(1, 3)
+	for i := 0; i < 3; i++ {
+		if !l.Allow("1.2.3.4") {
+			t.Fatalf("request %d should have been allowed within burst", i)
+		}
+	}
+	if l.Allow("1.2.3.4") {
+		t.Error("request beyond burst should have been rejected")
+	}
+}
+
+func TestIPRateLimiter_TracksIPsIndependently(t *testing.T) {
+	l := newIPRateLimiter(1, 1)
+	if !l.Allow("1.1.1.1") {
+		t.Error("first IP should be allowed")
+	}
+	if !l.Allow("2.2.2.2") {
+		t.Error("second IP should be allowed independently of the first")
+	}
+	if l.Allow("1.1.1.1") {
+		t.Error("first IP should be rate limited on its second request")
+	}
+}
+
+func TestIPRateLimiter_BoundsBucketCountByEvictingLeastRecentlyUsed(t *testing.T) {
+	l := newIPRateLimiter(1, 1)
+	for i := 0; i < maxRateLimiterBuckets+10; i++ {
+		l.Allow(fmt.Sprintf("10.0.%d.%d", i/256, i%256))
+	}
+	if got := l.ll.Len(); got != maxRateLimiterBuckets {
+		t.Errorf("expected bucket count capped at %d, got %d", maxRateLimiterBuckets, got)
+	}
+}
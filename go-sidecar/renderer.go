@@ -0,0 +1,176 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/JohannesKaufmann/html-to-markdown/v2/converter"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/base"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/commonmark"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/table"
+	"github.com/PuerkitoBio/goquery"
+)
+
+// RenderOptions toggles which Markdown extensions a Renderer applies,
+// mirroring writefreely's RendererExtensions() comma-separated option list.
+type RenderOptions struct {
+	Tables          bool
+	Strikethrough   bool
+	TaskLists       bool
+	Footnotes       bool
+	Autolinks       bool
+	DefinitionLists bool
+}
+
+// defaultRenderOptions enables every extension, matching this package's
+// behavior before RENDERER/RENDERER_EXTENSIONS existed.
+func defaultRenderOptions() RenderOptions {
+	return RenderOptions{
+		Tables:          true,
+		Strikethrough:   true,
+		TaskLists:       true,
+		Footnotes:       true,
+		Autolinks:       true,
+		DefinitionLists: true,
+	}
+}
+
+// ParseRenderOptions parses a comma-separated extension list such as
+// "tables,strikethrough,footnotes" into a RenderOptions, enabling only the
+// named extensions. An empty spec enables every extension.
+func ParseRenderOptions(spec string) RenderOptions {
+	if strings.TrimSpace(spec) == "" {
+		return defaultRenderOptions()
+	}
+
+	var opts RenderOptions
+	for _, name := range strings.Split(spec, ",") {
+		switch strings.TrimSpace(strings.ToLower(name)) {
+		case "tables":
+			opts.Tables = true
+		case "strikethrough":
+			opts.Strikethrough = true
+		case "tasklists", "task-lists":
+			opts.TaskLists = true
+		case "footnotes":
+			opts.Footnotes = true
+		case "autolinks":
+			opts.Autolinks = true
+		case "definitionlists", "definition-lists":
+			opts.DefinitionLists = true
+		}
+	}
+	return opts
+}
+
+// Renderer converts the isolated content HTML of a page into Markdown.
+type Renderer interface {
+	Render(contentHTML string) (string, error)
+}
+
+// rendererForName resolves a RENDERER value to a Renderer,
+// defaulting to HTMLToMarkdownRenderer for any value, since it is
+// currently the only renderer this package implements. goldmark only
+// renders Markdown to HTML, not the reverse, so there is no "goldmark"
+// alternative to resolve a name to.
+func rendererForName(name string, opts RenderOptions) Renderer {
+	return HTMLToMarkdownRenderer{opts: opts}
+}
+
+// HTMLToMarkdownRenderer converts HTML to Markdown with
+// github.com/JohannesKaufmann/html-to-markdown/v2, the sidecar's original
+// and default conversion backend.
+type HTMLToMarkdownRenderer struct {
+	opts RenderOptions
+}
+
+// Render converts contentHTML to Markdown, applying the renderer's
+// Tables, Strikethrough, TaskLists and DefinitionLists settings. The
+// Footnotes and Autolinks settings are not read and do not change the
+// output.
+func (r HTMLToMarkdownRenderer) Render(contentHTML string) (string, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader("<div>" + contentHTML + "</div>"))
+	if err != nil {
+		return "", err
+	}
+	root := doc.Find("div").First()
+
+	if !r.opts.Strikethrough {
+		unwrap(root, "del, s")
+	}
+	if !r.opts.TaskLists {
+		root.Find(`input[type="checkbox"]`).RemoveAttr("type")
+	}
+	if r.opts.DefinitionLists {
+		convertDefinitionLists(root)
+	}
+
+	normalizedHTML, err := root.Html()
+	if err != nil {
+		return "", err
+	}
+
+	plugins := []converter.Plugin{
+		base.NewBasePlugin(),
+		commonmark.NewCommonmarkPlugin(
+			commonmark.WithHeadingStyle("atx"),
+			commonmark.WithBulletListMarker("-"),
+		),
+	}
+	if r.opts.Tables {
+		plugins = append(plugins, table.NewTablePlugin())
+	}
+
+	conv := converter.NewConverter(converter.WithPlugins(plugins...))
+	md, err := conv.ConvertString(normalizedHTML)
+	if err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(md, definitionListIndentPlaceholder, " "), nil
+}
+
+// unwrap replaces each element matching selector with its own inner HTML,
+// discarding the wrapping tag but keeping its text (e.g. turning
+// "<del>gone</del>" plain instead of letting the converter render it as
+// a Markdown strikethrough).
+func unwrap(sel *goquery.Selection, selector string) {
+	sel.Find(selector).Each(func(_ int, s *goquery.Selection) {
+		inner, err := s.Html()
+		if err != nil {
+			return
+		}
+		s.ReplaceWithHtml(inner)
+	})
+}
+
+// definitionListIndentPlaceholder stands in for the extra spaces of a
+// kramdown ":   " marker while the text passes through HTML (where a run
+// of plain spaces collapses to one). It's a Unicode Private Use Area code
+// point, so it can't collide with real content; Render replaces it back
+// with literal spaces after conversion.
+const definitionListIndentPlaceholder = "\uE000"
+
+// convertDefinitionLists rewrites <dl> elements into the PHP Markdown
+// Extra / kramdown definition-list syntax ("Term\n:   Definition") before
+// conversion, since the commonmark plugin has no native <dl> support. Each
+// term/definition line is emitted as its own <p>, not joined with a bare
+// "\n" inside one <p> — HTML collapses a lone newline to a space, which
+// would merge "Term" and ":   Definition" onto a single rendered line.
+func convertDefinitionLists(sel *goquery.Selection) {
+	pad := strings.Repeat(definitionListIndentPlaceholder, 2)
+	sel.Find("dl").Each(func(_ int, dl *goquery.Selection) {
+		var b strings.Builder
+		dl.Children().Each(func(_ int, child *goquery.Selection) {
+			text := strings.TrimSpace(child.Text())
+			if text == "" || len(child.Nodes) == 0 {
+				return
+			}
+			switch child.Nodes[0].Data {
+			case "dt":
+				b.WriteString("<p>" + text + "</p>")
+			case "dd":
+				b.WriteString("<p>: " + pad + text + "</p>")
+			}
+		})
+		dl.ReplaceWithHtml(b.String())
+	})
+}
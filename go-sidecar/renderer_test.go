@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRenderOptions_EmptyEnablesEverything(t *testing.T) {
+	opts := ParseRenderOptions("")
+	if !opts.Tables || !opts.Strikethrough || !opts.TaskLists || !opts.Footnotes || !opts.Autolinks || !opts.DefinitionLists {
+		t.Errorf("expected every extension enabled for an empty spec, got %+v", opts)
+	}
+}
+
+func TestParseRenderOptions_ExplicitListEnablesOnlyNamed(t *testing.T) {
+	opts := ParseRenderOptions("tables, strikethrough")
+	if !opts.Tables || !opts.Strikethrough {
+		t.Errorf("expected tables and strikethrough enabled, got %+v", opts)
+	}
+	if opts.TaskLists || opts.Footnotes || opts.Autolinks || opts.DefinitionLists {
+		t.Errorf("expected unlisted extensions disabled, got %+v", opts)
+	}
+}
+
+func TestRendererForName_Defaults(t *testing.T) {
+	if _, ok := rendererForName("", defaultRenderOptions()).(HTMLToMarkdownRenderer); !ok {
+		t.Error("expected empty name to default to HTMLToMarkdownRenderer")
+	}
+	if _, ok := rendererForName("bogus", defaultRenderOptions()).(HTMLToMarkdownRenderer); !ok {
+		t.Error("expected unknown name to default to HTMLToMarkdownRenderer")
+	}
+	if _, ok := rendererForName("goldmark", defaultRenderOptions()).(HTMLToMarkdownRenderer); !ok {
+		t.Error("expected 'goldmark' to default to HTMLToMarkdownRenderer, since goldmark has no HTML-to-Markdown direction")
+	}
+}
+
+func TestHTMLToMarkdownRenderer_StrikethroughDisabled(t *testing.T) {
+	r := HTMLToMarkdownRenderer{opts: defaultRenderOptions()}
+	r.opts.Strikethrough = false
+	md, err := r.Render("<p>before <del>gone</del> after</p>")
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if strings.Contains(md, "~~") {
+		t.Errorf("expected no strikethrough markers with Strikethrough disabled, got %q", md)
+	}
+	if !strings.Contains(md, "gone") {
+		t.Errorf("expected struck-through text to survive as plain text, got %q", md)
+	}
+}
+
+func TestHTMLToMarkdownRenderer_DefinitionLists(t *testing.T) {
+	r := HTMLToMarkdownRenderer{opts: defaultRenderOptions()}
+	md, err := r.Render("<dl><dt>Term</dt><dd>Definition</dd></dl>")
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if !strings.Contains(md, "Term") || !strings.Contains(md, ":   Definition") {
+		t.Errorf("expected kramdown-style definition list syntax, got %q", md)
+	}
+}
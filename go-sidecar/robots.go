@@ -0,0 +1,117 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// robotsChecker is a minimal robots.txt gate for the batch CLI: it fetches
+// and caches each host's robots.txt once, and honors "Disallow" rules under
+// "User-agent: *". It does not implement the full spec (no Allow-rule
+// precedence, no wildcard/$ matching) — just enough to keep a crawl polite.
+type robotsChecker struct {
+	client    *http.Client
+	userAgent string
+
+	mu    sync.Mutex
+	rules map[string][]string // host -> disallowed path prefixes
+}
+
+func newRobotsChecker(client *http.Client, userAgent string) *robotsChecker {
+	return &robotsChecker{client: client, userAgent: userAgent, rules: make(map[string][]string)}
+}
+
+// Allowed reports whether rawURL may be fetched, fetching and caching the
+// host's robots.txt on first use. A robots.txt that fails to fetch is
+// treated as "allow everything", since a missing or broken robots.txt is
+// not a statement of intent to block crawlers.
+func (c *robotsChecker) Allowed(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return true
+	}
+
+	disallowed := c.disallowedPaths(u)
+	for _, prefix := range disallowed {
+		if prefix != "" && strings.HasPrefix(u.Path, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *robotsChecker) disallowedPaths(u *url.URL) []string {
+	host := u.Scheme + "://" + u.Host
+
+	c.mu.Lock()
+	if rules, ok := c.rules[host]; ok {
+		c.mu.Unlock()
+		return rules
+	}
+	c.mu.Unlock()
+
+	rules := c.fetchRules(host)
+
+	c.mu.Lock()
+	c.rules[host] = rules
+	c.mu.Unlock()
+
+	return rules
+}
+
+func (c *robotsChecker) fetchRules(host string) []string {
+	req, err := http.NewRequest(http.MethodGet, host+"/robots.txt", nil)
+	if err != nil {
+		return nil
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil
+	}
+
+	return parseRobotsDisallow(string(body))
+}
+
+// parseRobotsDisallow extracts "Disallow:" path prefixes that apply to the
+// "*" user-agent group.
+func parseRobotsDisallow(body string) []string {
+	var disallowed []string
+	appliesToAll := false
+
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			appliesToAll = value == "*"
+		case "disallow":
+			if appliesToAll && value != "" {
+				disallowed = append(disallowed, value)
+			}
+		}
+	}
+	return disallowed
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestParseRobotsDisallow_MatchesWildcardGroup(t *testing.T) {
+	body := "User-agent: *\nDisallow: /admin\nDisallow: /private\n"
+	rules := parseRobotsDisallow(body)
+	if len(rules) != 2 || rules[0] != "/admin" || rules[1] != "/private" {
+		t.Errorf("expected [/admin /private], got %v", rules)
+	}
+}
+
+func TestParseRobotsDisallow_IgnoresOtherUserAgents(t *testing.T) {
+	body := "User-agent: SomeOtherBot\nDisallow: /admin\n"
+	if rules := parseRobotsDisallow(body); len(rules) != 0 {
+		t.Errorf("expected no rules for a non-wildcard group, got %v", rules)
+	}
+}
+
+func TestRobotsChecker_AllowsWhenFetchFails(t *testing.T) {
+	c := newRobotsChecker(&http.Client{}, "test-agent/1.0")
+	if rules := c.fetchRules("http://this-host-does-not-resolve.invalid"); rules != nil {
+		t.Errorf("expected nil rules on fetch failure, got %v", rules)
+	}
+}